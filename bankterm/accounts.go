@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+func accounts(args []string) error {
+	var c commonFlags
+	fs := newFlagSet("accounts", &c)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	client, err := connect(&c)
+	if err != nil {
+		return err
+	}
+
+	acs, err := client.Accounts()
+	if err != nil {
+		return err
+	}
+
+	switch c.format {
+	case "json":
+		return writeJSON(acs)
+	case "table":
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"ID", "Description", "Sort Code", "Account Number", "Created"})
+		for _, ac := range acs {
+			table.Append([]string{ac.ID, ac.Description, ac.SortCode, ac.AccountNumber, ac.Created.Format(time.RFC3339)})
+		}
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.Render()
+		return nil
+	}
+
+	return usageError{fmt.Sprintf("unknown format %q", c.format)}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/sjwhitworth/gomondo/internal/mondoutil"
+)
+
+func balance(args []string) error {
+	var c commonFlags
+	fs := newFlagSet("balance", &c)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	client, err := connect(&c)
+	if err != nil {
+		return err
+	}
+
+	ac, err := selectAccount(client, c.account)
+	if err != nil {
+		return err
+	}
+
+	b, err := client.Balance(ac.ID)
+	if err != nil {
+		return err
+	}
+
+	switch c.format {
+	case "json":
+		return writeJSON(b)
+	case "table":
+		fmt.Printf("%v\n", ac.Description)
+		fmt.Printf("Balance:     %v\n", mondoutil.FormatAmount(b.Balance, b.Currency))
+		fmt.Printf("Spent today: %v\n", mondoutil.FormatAmount(-b.SpendToday, b.Currency))
+		return nil
+	}
+
+	return usageError{fmt.Sprintf("unknown format %q", c.format)}
+}
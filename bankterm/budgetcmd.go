@@ -0,0 +1,83 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/sjwhitworth/gomondo/budget"
+)
+
+func budgetCmd(args []string) error {
+	var c commonFlags
+	fs := newFlagSet("budget", &c)
+	configFile := fs.String("config", "budgets.json", "JSON file of budgets")
+	stateFile := fs.String("state", "budget-state.json", "file recording which alerts have been posted")
+	interval := fs.Duration("interval", 0, "poll the API this often; by default budgets are checked once")
+	listen := fs.String("listen", "", "address to receive webhook events on, for live alerts")
+	token := fs.String("token", os.Getenv("MONDO_WEBHOOK_TOKEN"), "token webhook events must carry in their token query parameter; defaults to MONDO_WEBHOOK_TOKEN, and is required with -listen")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if *listen != "" && *token == "" {
+		return usageError{"-listen requires -token or MONDO_WEBHOOK_TOKEN, so that only Mondo can send events"}
+	}
+
+	cfg, err := budget.LoadConfig(*configFile)
+	if err != nil {
+		return err
+	}
+
+	store, err := budget.OpenFileStore(*stateFile)
+	if err != nil {
+		return err
+	}
+
+	client, err := connect(&c)
+	if err != nil {
+		return err
+	}
+
+	ac, err := selectAccount(client, c.account)
+	if err != nil {
+		return err
+	}
+
+	engine := budget.NewEngine(client, ac.ID, cfg, store)
+	engine.Token = *token
+	if err := engine.Poll(time.Now()); err != nil {
+		return err
+	}
+
+	// errc receives the error that stopped the webhook listener, so that the command exits rather than waiting on a listener that is gone. It is left nil, and so never ready, when there is no listener.
+	var errc chan error
+	if *listen != "" {
+		slog.Info("Listening for webhook events", "addr", *listen)
+		errc = make(chan error, 1)
+		go func() {
+			errc <- http.ListenAndServe(*listen, engine)
+		}()
+	}
+
+	if *interval <= 0 {
+		if errc == nil {
+			return nil
+		}
+		return <-errc
+	}
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-errc:
+			return err
+		case <-ticker.C:
+			if err := engine.Poll(time.Now()); err != nil {
+				slog.Error("Error checking budgets", "error", err)
+			}
+		}
+	}
+}
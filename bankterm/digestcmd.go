@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/sjwhitworth/gomondo/digest"
+)
+
+func digestCmd(args []string) error {
+	var c commonFlags
+	fs := newFlagSet("digest", &c)
+	configFile := fs.String("config", "digests.json", "JSON file of digests to post")
+	stateFile := fs.String("state", "digest-state.json", "file recording which digests have been posted")
+	once := fs.Bool("once", false, "post any digests that are due, then exit, rather than running as a scheduler")
+	preview := fs.Bool("preview", false, "print each digest as it would be posted now, without posting it")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg, err := digest.LoadConfig(*configFile)
+	if err != nil {
+		return err
+	}
+
+	client, err := connect(&c)
+	if err != nil {
+		return err
+	}
+
+	ac, err := selectAccount(client, c.account)
+	if err != nil {
+		return err
+	}
+
+	if *preview {
+		scheduler := digest.NewScheduler(client, ac.ID, cfg, nil)
+		for _, d := range cfg.Digests {
+			item, err := scheduler.Render(d, time.Now())
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%v:\n  %v\n  %v\n\n", d.Name, item.Title, item.Body)
+		}
+		return nil
+	}
+
+	store, err := digest.OpenFileStore(*stateFile)
+	if err != nil {
+		return err
+	}
+	scheduler := digest.NewScheduler(client, ac.ID, cfg, store)
+
+	tick := func() error {
+		posted, err := scheduler.Tick(time.Now())
+		for _, name := range posted {
+			slog.Info("Posted digest", "digest", name)
+		}
+		return err
+	}
+
+	// With -once, failures are returned so that cron and other callers see them in the exit code.
+	if *once {
+		return tick()
+	}
+
+	for {
+		if err := tick(); err != nil {
+			slog.Error("Error posting digests", "error", err)
+		}
+		time.Sleep(time.Minute)
+	}
+}
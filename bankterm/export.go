@@ -0,0 +1,252 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/sjwhitworth/gomondo"
+	"github.com/sjwhitworth/gomondo/internal/mondoutil"
+)
+
+// transactionColumns is the column order used by every export format. Append new columns to the end, so that existing spreadsheets keep working.
+var transactionColumns = []string{
+	"id",
+	"created",
+	"settled",
+	"description",
+	"amount",
+	"currency",
+	"balance",
+	"category",
+	"is_load",
+	"notes",
+	"merchant_name",
+	"merchant_city",
+	"merchant_postcode",
+	"merchant_latitude",
+	"merchant_longitude",
+}
+
+// transactionRecord is a Transaction flattened for export. Its fields are in the same order as transactionColumns.
+type transactionRecord struct {
+	ID                string `json:"id"`
+	Created           string `json:"created"`
+	Settled           string `json:"settled"`
+	Description       string `json:"description"`
+	Amount            string `json:"amount"`
+	Currency          string `json:"currency"`
+	Balance           string `json:"balance"`
+	Category          string `json:"category"`
+	IsLoad            bool   `json:"is_load"`
+	Notes             string `json:"notes"`
+	MerchantName      string `json:"merchant_name"`
+	MerchantCity      string `json:"merchant_city"`
+	MerchantPostcode  string `json:"merchant_postcode"`
+	MerchantLatitude  string `json:"merchant_latitude"`
+	MerchantLongitude string `json:"merchant_longitude"`
+}
+
+func newTransactionRecord(t mondo.Transaction) transactionRecord {
+	r := transactionRecord{
+		ID:               t.ID,
+		Created:          t.Created,
+		Settled:          t.Settled,
+		Description:      t.Description,
+		Amount:           mondoutil.FormatDecimal(t.Amount, t.Currency),
+		Currency:         t.Currency,
+		Balance:          mondoutil.FormatDecimal(t.AccountBalance, t.Currency),
+		Category:         t.Category,
+		IsLoad:           t.IsLoad,
+		Notes:            t.Notes,
+		MerchantName:     merchantName(t),
+		MerchantCity:     t.Merchant.Address.City,
+		MerchantPostcode: t.Merchant.Address.Postcode,
+	}
+
+	// Leave coordinates blank rather than reporting a merchant at 0,0.
+	if t.Merchant.Address.Latitude != 0 || t.Merchant.Address.Longitude != 0 {
+		r.MerchantLatitude = strconv.FormatFloat(t.Merchant.Address.Latitude, 'f', -1, 64)
+		r.MerchantLongitude = strconv.FormatFloat(t.Merchant.Address.Longitude, 'f', -1, 64)
+	}
+
+	return r
+}
+
+func (r transactionRecord) strings() []string {
+	return []string{
+		r.ID,
+		r.Created,
+		r.Settled,
+		r.Description,
+		r.Amount,
+		r.Currency,
+		r.Balance,
+		r.Category,
+		strconv.FormatBool(r.IsLoad),
+		r.Notes,
+		r.MerchantName,
+		r.MerchantCity,
+		r.MerchantPostcode,
+		r.MerchantLatitude,
+		r.MerchantLongitude,
+	}
+}
+
+// merchantName returns the name to show for the merchant of a transaction. Top ups and other transactions made by Mondo itself have no merchant.
+func merchantName(t mondo.Transaction) string {
+	if t.Category == "mondo" {
+		return "Mondo"
+	}
+	return t.Merchant.Name
+}
+
+// transactionWriter writes transactions in an export format. Write is called with each page of transactions as it is fetched, and Close once all pages have been written.
+type transactionWriter interface {
+	Write(transactions []mondo.Transaction) error
+	Close() error
+}
+
+// newTransactionWriter returns a transactionWriter for format, writing to w.
+func newTransactionWriter(format string, w io.Writer) (transactionWriter, error) {
+	switch format {
+	case "csv":
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case "json":
+		return &jsonArrayWriter{w: w}, nil
+	case "jsonl":
+		return &jsonLinesWriter{enc: json.NewEncoder(w)}, nil
+	case "table":
+		return &tableWriter{w: w}, nil
+	}
+
+	return nil, usageError{fmt.Sprintf("unknown format %q: use csv, json, jsonl or table", format)}
+}
+
+// csvWriter writes a header row followed by one row per transaction.
+type csvWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func (c *csvWriter) writeHeader() error {
+	if c.headerWritten {
+		return nil
+	}
+	c.headerWritten = true
+	return c.w.Write(transactionColumns)
+}
+
+func (c *csvWriter) Write(transactions []mondo.Transaction) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+
+	for _, t := range transactions {
+		if err := c.w.Write(newTransactionRecord(t).strings()); err != nil {
+			return err
+		}
+	}
+
+	// Flush every page, so that exports of a full history are not buffered in memory.
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonLinesWriter writes one JSON object per line.
+type jsonLinesWriter struct {
+	enc *json.Encoder
+}
+
+func (j *jsonLinesWriter) Write(transactions []mondo.Transaction) error {
+	for _, t := range transactions {
+		if err := j.enc.Encode(newTransactionRecord(t)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (j *jsonLinesWriter) Close() error {
+	return nil
+}
+
+// jsonArrayWriter writes a single JSON array, one element at a time.
+type jsonArrayWriter struct {
+	w       io.Writer
+	started bool
+}
+
+func (j *jsonArrayWriter) Write(transactions []mondo.Transaction) error {
+	for _, t := range transactions {
+		b, err := json.Marshal(newTransactionRecord(t))
+		if err != nil {
+			return err
+		}
+
+		sep := ",\n  "
+		if !j.started {
+			sep = "[\n  "
+			j.started = true
+		}
+
+		if _, err := io.WriteString(j.w, sep); err != nil {
+			return err
+		}
+		if _, err := j.w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (j *jsonArrayWriter) Close() error {
+	end := "\n]\n"
+	if !j.started {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(j.w, end)
+	return err
+}
+
+// tableWriter renders a table once all transactions have been written. Column widths depend on every row, so unlike the other formats it has to hold all of the transactions in memory.
+type tableWriter struct {
+	w            io.Writer
+	transactions []mondo.Transaction
+}
+
+func (t *tableWriter) Write(transactions []mondo.Transaction) error {
+	t.transactions = append(t.transactions, transactions...)
+	return nil
+}
+
+func (t *tableWriter) Close() error {
+	if len(t.transactions) == 0 {
+		return nil
+	}
+
+	// Render a lovely table of all of your transactions.
+	transactionsToTable(t.w, t.transactions...).Render()
+	return nil
+}
+
+func transactionsToTable(w io.Writer, transactions ...mondo.Transaction) *tablewriter.Table {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"ID", "Time", "Merchant Name", "Amount", "Category", "Balance"})
+	for _, v := range transactions {
+		table.Append([]string{v.ID, v.Created, merchantName(v), mondoutil.FormatAmount(v.Amount, v.Currency), v.Category, mondoutil.FormatAmount(v.AccountBalance, v.Currency)})
+	}
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	return table
+}
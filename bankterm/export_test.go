@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/stretchr/testify/assert"
+)
+
+var exportFixture = []mondo.Transaction{
+	{
+		ID:             "tx_1",
+		Created:        "2015-08-22T12:20:18Z",
+		Settled:        "2015-08-23T12:20:18Z",
+		Description:    "THE DE BEAUVOIR DELI C LONDON        GBR",
+		Amount:         -510,
+		Currency:       "GBP",
+		AccountBalance: 13013,
+		Category:       "eating_out",
+		Notes:          "Salmon sandwich, \"no\" crusts",
+		Merchant: mondo.Merchant{
+			Name: "The De Beauvoir Deli Co.",
+			Address: mondo.MerchantAddress{
+				City:      "London",
+				Postcode:  "N1 3JD",
+				Latitude:  51.54151,
+				Longitude: -0.08482400000002599,
+			},
+		},
+	},
+	{
+		ID:             "tx_2",
+		Created:        "2015-08-23T09:00:00Z",
+		Description:    "Top up",
+		Amount:         10000,
+		Currency:       "GBP",
+		AccountBalance: 23013,
+		Category:       "mondo",
+		IsLoad:         true,
+	},
+}
+
+// The expected output of each format for exportFixture. Changing these breaks the scripts and spreadsheets that read them.
+const (
+	exportCSV = `id,created,settled,description,amount,currency,balance,category,is_load,notes,merchant_name,merchant_city,merchant_postcode,merchant_latitude,merchant_longitude
+tx_1,2015-08-22T12:20:18Z,2015-08-23T12:20:18Z,THE DE BEAUVOIR DELI C LONDON        GBR,-5.10,GBP,130.13,eating_out,false,"Salmon sandwich, ""no"" crusts",The De Beauvoir Deli Co.,London,N1 3JD,51.54151,-0.08482400000002599
+tx_2,2015-08-23T09:00:00Z,,Top up,100.00,GBP,230.13,mondo,true,,Mondo,,,,
+`
+
+	exportJSONL = `{"id":"tx_1","created":"2015-08-22T12:20:18Z","settled":"2015-08-23T12:20:18Z","description":"THE DE BEAUVOIR DELI C LONDON        GBR","amount":"-5.10","currency":"GBP","balance":"130.13","category":"eating_out","is_load":false,"notes":"Salmon sandwich, \"no\" crusts","merchant_name":"The De Beauvoir Deli Co.","merchant_city":"London","merchant_postcode":"N1 3JD","merchant_latitude":"51.54151","merchant_longitude":"-0.08482400000002599"}
+{"id":"tx_2","created":"2015-08-23T09:00:00Z","settled":"","description":"Top up","amount":"100.00","currency":"GBP","balance":"230.13","category":"mondo","is_load":true,"notes":"","merchant_name":"Mondo","merchant_city":"","merchant_postcode":"","merchant_latitude":"","merchant_longitude":""}
+`
+
+	exportJSON = `[
+  {"id":"tx_1","created":"2015-08-22T12:20:18Z","settled":"2015-08-23T12:20:18Z","description":"THE DE BEAUVOIR DELI C LONDON        GBR","amount":"-5.10","currency":"GBP","balance":"130.13","category":"eating_out","is_load":false,"notes":"Salmon sandwich, \"no\" crusts","merchant_name":"The De Beauvoir Deli Co.","merchant_city":"London","merchant_postcode":"N1 3JD","merchant_latitude":"51.54151","merchant_longitude":"-0.08482400000002599"},
+  {"id":"tx_2","created":"2015-08-23T09:00:00Z","settled":"","description":"Top up","amount":"100.00","currency":"GBP","balance":"230.13","category":"mondo","is_load":true,"notes":"","merchant_name":"Mondo","merchant_city":"","merchant_postcode":"","merchant_latitude":"","merchant_longitude":""}
+]
+`
+)
+
+func TestTransactionWriters(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+		empty  string
+	}{
+		{"csv", exportCSV, "id,created,settled,description,amount,currency,balance,category,is_load,notes,merchant_name,merchant_city,merchant_postcode,merchant_latitude,merchant_longitude\n"},
+		{"jsonl", exportJSONL, ""},
+		{"json", exportJSON, "[]\n"},
+	}
+
+	for _, test := range tests {
+		// Writing a page at a time gives the same output as writing everything at once.
+		for _, pages := range [][][]mondo.Transaction{
+			{exportFixture},
+			{exportFixture[:1], nil, exportFixture[1:]},
+		} {
+			var buf bytes.Buffer
+			w, err := newTransactionWriter(test.format, &buf)
+			assert.NoError(t, err)
+			for _, page := range pages {
+				assert.NoError(t, w.Write(page))
+			}
+			assert.NoError(t, w.Close())
+			assert.Equal(t, test.want, buf.String(), test.format)
+		}
+
+		var buf bytes.Buffer
+		w, err := newTransactionWriter(test.format, &buf)
+		assert.NoError(t, err)
+		assert.NoError(t, w.Close())
+		assert.Equal(t, test.empty, buf.String(), test.format)
+	}
+
+	_, err := newTransactionWriter("xml", &bytes.Buffer{})
+	assert.IsType(t, usageError{}, err)
+}
+
+func TestTransactionColumns(t *testing.T) {
+	// The JSON fields and CSV values of a record follow transactionColumns.
+	typ := reflect.TypeOf(transactionRecord{})
+	assert.Equal(t, len(transactionColumns), typ.NumField())
+	for i, column := range transactionColumns {
+		assert.Equal(t, column, typ.Field(i).Tag.Get("json"))
+	}
+
+	r := newTransactionRecord(exportFixture[0])
+	assert.Len(t, r.strings(), len(transactionColumns))
+
+	var fields map[string]interface{}
+	b, err := json.Marshal(r)
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(b, &fields))
+	for i, column := range transactionColumns {
+		assert.Equal(t, r.strings()[i], fmtField(fields[column]), column)
+	}
+}
+
+// fmtField formats a decoded JSON field as it appears in CSV.
+func fmtField(v interface{}) string {
+	if b, ok := v.(bool); ok {
+		if b {
+			return "true"
+		}
+		return "false"
+	}
+	return v.(string)
+}
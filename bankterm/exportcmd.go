@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/sjwhitworth/gomondo/export"
+)
+
+func exportCmd(args []string) error {
+	var c commonFlags
+	fs := newFlagSet("export", &c)
+	ofx := fs.Bool("ofx", false, "export an OFX 2.1.1 bank statement")
+	qif := fs.Bool("qif", false, "export a QIF file")
+	ledger := fs.Bool("ledger", false, "export ledger-cli journal entries")
+	beancount := fs.Bool("beancount", false, "export beancount entries")
+	rulesFile := fs.String("rules", "", "JSON file mapping categories and merchants to ledger or beancount accounts")
+	out := fs.String("o", "", "file to write to (defaults to stdout)")
+	appendOut := fs.Bool("append", false, "append ledger or beancount entries to the -o file, skipping transactions already in it")
+	sinceFlag := fs.String("since", "", "only export transactions created at or after this date (YYYY-MM-DD or RFC3339)")
+	beforeFlag := fs.String("before", "", "only export transactions created before this date (YYYY-MM-DD or RFC3339)")
+	includePending := fs.Bool("include-pending", false, "include transactions that have not settled yet")
+	qifDateFormat := fs.String("qif-date-format", export.DefaultQIFDateFormat, "date layout for QIF files, in Go time format")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var err error
+	formats := 0
+	for _, f := range []bool{*ofx, *qif, *ledger, *beancount} {
+		if f {
+			formats++
+		}
+	}
+	if formats != 1 {
+		return usageError{"export requires exactly one of -ofx, -qif, -ledger or -beancount"}
+	}
+
+	if *appendOut && (*out == "" || !(*ledger || *beancount)) {
+		return usageError{"-append requires -o and one of -ledger or -beancount"}
+	}
+
+	// Entries already in the journal are never rewritten, so a transaction appended while pending would keep its pending amount and date once it settled.
+	if *appendOut && *includePending {
+		return usageError{"-append cannot be used with -include-pending: pending transactions are appended by a later run, once they have settled"}
+	}
+
+	accounts := export.DefaultAccountMap()
+	if *rulesFile != "" {
+		if accounts, err = export.LoadAccountMap(*rulesFile); err != nil {
+			return err
+		}
+	}
+
+	opts := export.Options{IncludePending: *includePending, QIFDateFormat: *qifDateFormat}
+	if *appendOut {
+		if opts.SkipIDs, err = exportedIDs(*out); err != nil {
+			return err
+		}
+	}
+
+	since, err := parseTime(*sinceFlag)
+	if err != nil {
+		return err
+	}
+
+	before, err := parseTime(*beforeFlag)
+	if err != nil {
+		return err
+	}
+
+	client, err := connect(&c)
+	if err != nil {
+		return err
+	}
+
+	ac, err := selectAccount(client, c.account)
+	if err != nil {
+		return err
+	}
+
+	// Statements need the full date range up front, so collect every transaction before writing.
+	var transactions []mondo.Transaction
+	err = eachPage(client, ac.ID, since, before, 0, func(page []mondo.Transaction) error {
+		transactions = append(transactions, page...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if *appendOut {
+			flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		}
+
+		f, err := os.OpenFile(*out, flags, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch {
+	case *ofx:
+		err = export.WriteOFX(w, *ac, transactions, opts)
+	case *qif:
+		err = export.WriteQIF(w, *ac, transactions, opts)
+	case *ledger:
+		err = export.WriteLedger(w, transactions, accounts, opts)
+	case *beancount:
+		err = export.WriteBeancount(w, transactions, accounts, opts)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to export transactions: %v", err)
+	}
+
+	return nil
+}
+
+// exportedIDs returns the IDs of transactions already in the journal at path. A journal that does not exist yet holds none.
+func exportedIDs(path string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return export.ExportedIDs(f)
+}
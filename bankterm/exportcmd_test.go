@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportFlags(t *testing.T) {
+	tests := [][]string{
+		{},
+		{"-ofx", "-qif"},
+		{"-ofx", "-append", "-o", "statement.ofx"},
+		{"-ledger", "-append"},
+		{"-ledger", "-append", "-include-pending", "-o", "books.ledger"},
+	}
+
+	for _, args := range tests {
+		assert.IsType(t, usageError{}, exportCmd(args), "%v", args)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/sjwhitworth/gomondo"
+)
+
+func feed(args []string) error {
+	if len(args) == 0 || args[0] != "post" {
+		return usageError{"usage: bankterm feed post -title TITLE [-body BODY] [-image URL] [-url URL]"}
+	}
+
+	var c commonFlags
+	fs := newFlagSet("feed post", &c)
+	title := fs.String("title", "", "title of the feed item")
+	body := fs.String("body", "", "body of the feed item")
+	imageURL := fs.String("image", "https://blog.golang.org/gopher/gopher.png", "URL of the image to show")
+	bgColor := fs.String("bg-color", "", "background colour, as a hex code")
+	bodyColor := fs.String("body-color", "", "body colour, as a hex code")
+	titleColor := fs.String("title-color", "", "title colour, as a hex code")
+	openURL := fs.String("url", "", "URL to open when the feed item is tapped")
+	itemType := fs.String("type", mondo.FeedItemTypeBasic, "type of feed item")
+	if err := parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+
+	item := &mondo.FeedItem{
+		Type:            *itemType,
+		Title:           *title,
+		Body:            *body,
+		ImageURL:        *imageURL,
+		URL:             *openURL,
+		BackgroundColor: *bgColor,
+		BodyColor:       *bodyColor,
+		TitleColor:      *titleColor,
+	}
+	if err := item.Validate(); err != nil {
+		return usageError{err.Error()}
+	}
+
+	client, err := connect(&c)
+	if err != nil {
+		return err
+	}
+
+	ac, err := selectAccount(client, c.account)
+	if err != nil {
+		return err
+	}
+
+	// There is no way to delete a feed item currently, so use with caution.
+	if err := client.PostFeedItem(ac.ID, item); err != nil {
+		return err
+	}
+
+	fmt.Printf("Posted %q to the feed of %v\n", *title, ac.Description)
+	return nil
+}
@@ -1,77 +1,254 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"time"
 
-	log "github.com/cihub/seelog"
-	"github.com/olekukonko/tablewriter"
 	"github.com/sjwhitworth/gomondo"
+	"github.com/sjwhitworth/gomondo/cache"
 )
 
+// Exit codes returned by bankterm.
+const (
+	exitOK       = 0
+	exitError    = 1
+	exitUsage    = 2
+	exitAuth     = 3
+	exitNotFound = 4
+)
+
+const usage = `Usage: bankterm <command> [flags]
+
+Commands:
+  accounts        list your accounts
+  balance         show the balance of an account
+  tx list         list transactions
+  tx show ID      show a single transaction
+  feed post       post an item to your feed
+  export          export transactions as OFX, QIF, ledger or beancount
+  report          summarise spending by category, merchant or period
+  budget          check monthly budgets, and post alerts to your feed
+  digest          post daily and weekly spending digests to your feed
+  subscriptions   list recurring payments and predict the next charge
+  rules           tag transactions using rules, with -apply to save the tags
+  sync            copy transactions into the local cache, for use with -offline
+  tui             browse and search transactions full screen
+
+Run "bankterm <command> -h" for the flags each command accepts.
+Credentials are read from MONDO_CLIENT_ID, MONDO_CLIENT_SECRET, MONDO_USERNAME and MONDO_PASSWORD.
+Set MONDO_API_URL to talk to an API other than production, and MONDO_CACHE_DIR to move the local cache.
+Set MONDO_LOG_LEVEL to debug to log each request to the API.
+`
+
+// usageError is returned when a command is invoked incorrectly.
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
 func main() {
-	defer log.Flush()
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	// Log to stderr, so that output written to stdout can be piped elsewhere. Requests to the API are logged at debug level.
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel()})))
+	mondo.DefaultLogger = slog.Default()
+
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return exitUsage
+	}
+
+	var err error
+	switch args[0] {
+	case "accounts":
+		err = accounts(args[1:])
+	case "balance":
+		err = balance(args[1:])
+	case "tx":
+		err = tx(args[1:])
+	case "feed":
+		err = feed(args[1:])
+	case "export":
+		err = exportCmd(args[1:])
+	case "report":
+		err = reportCmd(args[1:])
+	case "budget":
+		err = budgetCmd(args[1:])
+	case "digest":
+		err = digestCmd(args[1:])
+	case "subscriptions":
+		err = subscriptionsCmd(args[1:])
+	case "rules":
+		err = rulesCmd(args[1:])
+	case "sync":
+		err = syncCmd(args[1:])
+	case "tui":
+		err = tuiCmd(args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprint(os.Stdout, usage)
+		return exitOK
+	default:
+		err = usageError{fmt.Sprintf("unknown command %q", args[0])}
+	}
+
+	return exitCode(err)
+}
+
+// exitCode logs err, and maps it to the exit code bankterm should return.
+func exitCode(err error) int {
+	switch err {
+	case nil:
+		return exitOK
+	case flag.ErrHelp:
+		return exitUsage
+	case mondo.ErrUnauthenticatedRequest:
+		slog.Error("Failed to authenticate with Mondo", "error", err)
+		return exitAuth
+	case mondo.ErrNoTransactionFound:
+		slog.Error(err.Error())
+		return exitNotFound
+	}
+
+	if _, ok := err.(usageError); ok {
+		fmt.Fprintf(os.Stderr, "%v\n\n%v", err, usage)
+		return exitUsage
+	}
+
+	slog.Error(err.Error())
+	return exitError
+}
+
+// commonFlags are the flags shared by commands that operate on an account.
+type commonFlags struct {
+	account string
+	format  string
+	offline bool
+	cache   string
+}
+
+func newFlagSet(name string, c *commonFlags) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&c.account, "account", "", "account ID or description to use (defaults to the first account)")
+	fs.StringVar(&c.format, "format", "table", "output format: table or json")
+	fs.BoolVar(&c.offline, "offline", false, "read from the local cache filled by \"bankterm sync\" instead of the API")
+	fs.StringVar(&c.cache, "cache", defaultCacheDir(), "directory of the local cache")
+	return fs
+}
 
+// parseFlags parses args, reporting any error as flag.ErrHelp since the flag package has already printed it.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return flag.ErrHelp
+	}
+	return nil
+}
+
+// authenticate returns an authenticated MondoClient using credentials from the environment.
+func authenticate() (*mondo.MondoClient, error) {
 	clientId := os.Getenv("MONDO_CLIENT_ID")
 	clientSecret := os.Getenv("MONDO_CLIENT_SECRET")
 	userName := os.Getenv("MONDO_USERNAME")
 	password := os.Getenv("MONDO_PASSWORD")
 
+	if u := os.Getenv("MONDO_API_URL"); u != "" {
+		mondo.BaseMondoURL = u
+	}
+
 	// Authenticate with Mondo, and return an authenticated MondoClient.
 	client, err := mondo.Authenticate(clientId, clientSecret, userName, password)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+
+	slog.Debug("Authenticated with Mondo successfully!")
+	return client, nil
+}
+
+// connect returns the local cache if -offline was given, and otherwise authenticates with the API.
+func connect(c *commonFlags) (mondo.Client, error) {
+	if !c.offline {
+		return authenticate()
+	}
+
+	store, err := cache.Open(c.cache, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if acs, _ := store.Accounts(); len(acs) == 0 {
+		return nil, fmt.Errorf("the cache in %v is empty: run \"bankterm sync\" first", c.cache)
 	}
+	return store, nil
+}
 
-	log.Infof("Authenticated with Mondo successfully!")
+// defaultCacheDir returns MONDO_CACHE_DIR, or a bankterm directory in the user's cache directory.
+func defaultCacheDir() string {
+	if dir := os.Getenv("MONDO_CACHE_DIR"); dir != "" {
+		return dir
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "bankterm")
+	}
+	return ".bankterm-cache"
+}
 
-	// Retrieve all of the accounts.
+// selectAccount finds the account matching the -account flag by ID or description. If the flag is empty, the first account is used.
+func selectAccount(client mondo.Client, selector string) (*mondo.Account, error) {
 	acs, err := client.Accounts()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	if len(acs) == 0 {
-		log.Errorf("No accounts with Mondo found :( Sign up!")
-		return
+		return nil, fmt.Errorf("no accounts with Mondo found :( Sign up!")
 	}
 
-	// Grab our account ID.
-	accountId := acs[0].ID
+	if selector == "" {
+		return &acs[0], nil
+	}
 
-	// Get all transactions. You can also get a specific transaction by ID.
-	transactions, err := client.Transactions(accountId, "", "", 100)
-	if err != nil {
-		panic(err)
+	for i := range acs {
+		if acs[i].ID == selector || acs[i].Description == selector {
+			return &acs[i], nil
+		}
 	}
 
-	if len(transactions) == 0 {
-		log.Warnf("No transactions found. Sorry!")
-		return
+	return nil, fmt.Errorf("no account matching %q", selector)
+}
+
+// parseTime accepts either an RFC3339 timestamp or a date of the form 2006-01-02.
+func parseTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
 	}
 
-	// Render a lovely table of all of your transactions.
-	table := transactionsToTable(transactions...)
-	table.Render()
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
 
-	// Create a feed item in your feed.
-	// Don't run this, unless you want to spam yourself, as there is no way currently to delete a feed item.
-	// err = client.CreateFeedItem(id, "Hi there!", "https://blog.golang.org/gopher/gopher.png", "",
-	// t "", "", "This is a test item, from Go")
-	// if err != nil {
-	// 	panic(err)
-	// }
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return time.Time{}, usageError{fmt.Sprintf("invalid time %q: use YYYY-MM-DD or RFC3339", v)}
+	}
+	return t, nil
 }
 
-func transactionsToTable(transactions ...mondo.Transaction) *tablewriter.Table {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"ID", "Time", "Merchant Name", "Amount", "Category", "Balance"})
-	for _, v := range transactions {
-		if v.Category == "mondo" {
-			v.Merchant.Name = "Mondo"
+// logLevel returns the level set by MONDO_LOG_LEVEL, such as debug or warn, defaulting to info.
+func logLevel() slog.Level {
+	var level slog.Level
+	if v := os.Getenv("MONDO_LOG_LEVEL"); v != "" {
+		if err := level.UnmarshalText([]byte(v)); err != nil {
+			fmt.Fprintf(os.Stderr, "Ignoring MONDO_LOG_LEVEL: %v\n", err)
 		}
-		table.Append([]string{v.ID, v.Created, v.Merchant.Name, fmt.Sprintf("%v", v.Amount), v.Category, fmt.Sprintf("£%.2f", float64(v.AccountBalance)/100)})
 	}
-	table.SetAlignment(tablewriter.ALIGN_LEFT)
-	return table
+	return level
 }
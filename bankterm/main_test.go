@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"testing"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{nil, exitOK},
+		{flag.ErrHelp, exitUsage},
+		{usageError{"unknown command \"frobnicate\""}, exitUsage},
+		{mondo.ErrUnauthenticatedRequest, exitAuth},
+		{mondo.ErrNoTransactionFound, exitNotFound},
+		{fmt.Errorf("failed to get balance for account acc_1: 500 Internal Server Error"), exitError},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.code, exitCode(test.err), "%v", test.err)
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	assert.Equal(t, exitUsage, run(nil))
+	assert.Equal(t, exitUsage, run([]string{"frobnicate"}))
+	assert.Equal(t, exitUsage, run([]string{"tx"}))
+	assert.Equal(t, exitUsage, run([]string{"tx", "list", "-no-such-flag"}))
+	assert.Equal(t, exitOK, run([]string{"help"}))
+}
@@ -0,0 +1,13 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// writeJSON writes v to stdout as indented JSON.
+func writeJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/sjwhitworth/gomondo"
+	"github.com/sjwhitworth/gomondo/internal/mondoutil"
+	"github.com/sjwhitworth/gomondo/report"
+)
+
+func reportCmd(args []string) error {
+	var c commonFlags
+	fs := newFlagSet("report", &c)
+	by := fs.String("by", "category", "group transactions by category, merchant, day, week or month")
+	sinceFlag := fs.String("since", "", "only report on transactions created at or after this date (YYYY-MM-DD or RFC3339)")
+	beforeFlag := fs.String("before", "", "only report on transactions created before this date (YYYY-MM-DD or RFC3339)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	grouping, err := report.ParseGrouping(*by)
+	if err != nil {
+		return usageError{err.Error()}
+	}
+
+	if c.format != "table" && c.format != "json" {
+		return usageError{fmt.Sprintf("unknown format %q", c.format)}
+	}
+
+	since, err := parseTime(*sinceFlag)
+	if err != nil {
+		return err
+	}
+
+	before, err := parseTime(*beforeFlag)
+	if err != nil {
+		return err
+	}
+
+	client, err := connect(&c)
+	if err != nil {
+		return err
+	}
+
+	ac, err := selectAccount(client, c.account)
+	if err != nil {
+		return err
+	}
+
+	var transactions []mondo.Transaction
+	err = eachPage(client, ac.ID, since, before, 0, func(page []mondo.Transaction) error {
+		transactions = append(transactions, page...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	summary, err := report.Summarise(transactions, grouping)
+	if err != nil {
+		return err
+	}
+
+	if c.format == "json" {
+		return writeJSON(summary)
+	}
+
+	cur := summary.Currency
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{string(grouping), "Count", "Spend", "Average", "Share", "Income", "Top Ups"})
+	for _, row := range summary.Rows {
+		table.Append([]string{
+			row.Label,
+			strconv.Itoa(row.Count),
+			mondoutil.FormatAmount(row.Spend, cur),
+			mondoutil.FormatAmount(row.AverageSpend(), cur),
+			fmt.Sprintf("%.1f%%", row.SpendShare),
+			mondoutil.FormatAmount(row.Income, cur),
+			mondoutil.FormatAmount(row.TopUps, cur),
+		})
+	}
+
+	total := summary.Total
+	table.SetFooter([]string{
+		"Total",
+		strconv.Itoa(total.Count),
+		mondoutil.FormatAmount(total.Spend, cur),
+		mondoutil.FormatAmount(total.AverageSpend(), cur),
+		"100%",
+		mondoutil.FormatAmount(total.Income, cur),
+		mondoutil.FormatAmount(total.TopUps, cur),
+	})
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.Render()
+	return nil
+}
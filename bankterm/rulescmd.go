@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/sjwhitworth/gomondo"
+	"github.com/sjwhitworth/gomondo/rules"
+)
+
+func rulesCmd(args []string) error {
+	var c commonFlags
+	fs := newFlagSet("rules", &c)
+	rulesFile := fs.String("rules", "rules.json", "JSON file of tagging rules")
+	apply := fs.Bool("apply", false, "annotate transactions with the changes; by default they are only printed")
+	sinceFlag := fs.String("since", "", "only tag transactions created at or after this date (YYYY-MM-DD or RFC3339)")
+	beforeFlag := fs.String("before", "", "only tag transactions created before this date (YYYY-MM-DD or RFC3339)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if c.format != "table" && c.format != "json" {
+		return usageError{fmt.Sprintf("unknown format %q", c.format)}
+	}
+
+	since, err := parseTime(*sinceFlag)
+	if err != nil {
+		return err
+	}
+
+	before, err := parseTime(*beforeFlag)
+	if err != nil {
+		return err
+	}
+
+	rs, err := rules.LoadRules(*rulesFile)
+	if err != nil {
+		return err
+	}
+
+	client, err := connect(&c)
+	if err != nil {
+		return err
+	}
+
+	ac, err := selectAccount(client, c.account)
+	if err != nil {
+		return err
+	}
+
+	changes := []rules.Change{}
+	err = eachPage(client, ac.ID, since, before, 0, func(page []mondo.Transaction) error {
+		changes = append(changes, rs.Plan(page)...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.format == "json" {
+		if err := writeJSON(changes); err != nil {
+			return err
+		}
+	} else {
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"ID", "Description", "Rules", "Changes"})
+		for _, change := range changes {
+			var set []string
+			for _, k := range change.Keys() {
+				set = append(set, fmt.Sprintf("%v=%q", k, change.Metadata[k]))
+			}
+			table.Append([]string{change.TransactionID, change.Description, strings.Join(change.Rules, ", "), strings.Join(set, " ")})
+		}
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.Render()
+	}
+
+	if !*apply {
+		return nil
+	}
+
+	n, err := rules.Apply(client, changes)
+	slog.Info("Annotated transactions", "annotated", n, "matched", len(changes))
+	return err
+}
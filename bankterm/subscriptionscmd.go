@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/sjwhitworth/gomondo"
+	"github.com/sjwhitworth/gomondo/internal/mondoutil"
+	"github.com/sjwhitworth/gomondo/recurring"
+)
+
+func subscriptionsCmd(args []string) error {
+	var c commonFlags
+	fs := newFlagSet("subscriptions", &c)
+	sinceFlag := fs.String("since", "", "only look at transactions created at or after this date (YYYY-MM-DD or RFC3339); defaults to 13 months ago")
+	tolerance := fs.Float64("tolerance", 0.25, "how much, as a fraction, a charge may change from the last and still be the same payment")
+	all := fs.Bool("all", false, "include payments that look to have been cancelled")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if c.format != "table" && c.format != "json" {
+		return usageError{fmt.Sprintf("unknown format %q", c.format)}
+	}
+
+	since, err := parseTime(*sinceFlag)
+	if err != nil {
+		return err
+	}
+
+	// Annual payments need over a year of history to show up twice.
+	now := time.Now()
+	if since.IsZero() {
+		since = now.AddDate(-1, -1, 0)
+	}
+
+	client, err := connect(&c)
+	if err != nil {
+		return err
+	}
+
+	ac, err := selectAccount(client, c.account)
+	if err != nil {
+		return err
+	}
+
+	var transactions []mondo.Transaction
+	err = eachPage(client, ac.ID, since, time.Time{}, 0, func(page []mondo.Transaction) error {
+		transactions = append(transactions, page...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	detected, err := recurring.Detect(transactions, recurring.Options{AmountTolerance: *tolerance})
+	if err != nil {
+		return err
+	}
+
+	payments := []recurring.Payment{}
+	for _, p := range detected {
+		if *all || p.Active(now) {
+			payments = append(payments, p)
+		}
+	}
+
+	if c.format == "json" {
+		return writeJSON(payments)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Merchant", "Every", "Amount", "Charges", "Last", "Next", "Change"})
+	for _, p := range payments {
+		change := ""
+		if p.PriceIncreased() {
+			change = "up from " + mondoutil.FormatAmount(p.PreviousAmount, p.Currency)
+		}
+		table.Append([]string{
+			p.Merchant,
+			string(p.Period),
+			mondoutil.FormatAmount(p.NextAmount, p.Currency),
+			strconv.Itoa(p.Count),
+			p.Last.Format("2006-01-02"),
+			p.NextDate.Format("2006-01-02"),
+			change,
+		})
+	}
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.Render()
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/sjwhitworth/gomondo/cache"
+)
+
+func syncCmd(args []string) error {
+	var c commonFlags
+	fs := newFlagSet("sync", &c)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if c.offline {
+		return usageError{"sync cannot be run offline"}
+	}
+
+	client, err := authenticate()
+	if err != nil {
+		return err
+	}
+
+	store, err := cache.Open(c.cache, client)
+	if err != nil {
+		return err
+	}
+
+	// Sync every account, unless one was chosen.
+	acs, err := client.Accounts()
+	if err != nil {
+		return err
+	}
+	if c.account != "" {
+		ac, err := selectAccount(client, c.account)
+		if err != nil {
+			return err
+		}
+		acs = acs[:0]
+		acs = append(acs, *ac)
+	}
+
+	for _, ac := range acs {
+		result, err := store.Sync(ac.ID)
+		if err != nil {
+			return fmt.Errorf("failed to sync %v: %v", ac.Description, err)
+		}
+		slog.Info("Synced account", "account", ac.Description, "added", result.Added, "updated", result.Updated)
+	}
+
+	return nil
+}
@@ -0,0 +1,19 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+type terminal struct{}
+
+func openTerminal() (*terminal, error) {
+	return nil, fmt.Errorf("bankterm tui is not supported on this platform")
+}
+
+func (t *terminal) restore() error                  { return nil }
+func (t *terminal) size() (int, int, error)         { return 80, 24, nil }
+func (t *terminal) notifyResize(c chan<- os.Signal) {}
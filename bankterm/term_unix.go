@@ -0,0 +1,66 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+// terminal is a terminal switched into raw mode, so that keys are read as they are pressed and not echoed.
+type terminal struct {
+	fd  uintptr
+	old syscall.Termios
+}
+
+// openTerminal puts the terminal on stdin into raw mode. Call restore to put it back.
+func openTerminal() (*terminal, error) {
+	t := &terminal{fd: os.Stdin.Fd()}
+	if err := ioctl(t.fd, ioctlGetTermios, unsafe.Pointer(&t.old)); err != nil {
+		return nil, errNotTerminal
+	}
+
+	raw := t.old
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctl(t.fd, ioctlSetTermios, unsafe.Pointer(&raw)); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *terminal) restore() error {
+	return ioctl(t.fd, ioctlSetTermios, unsafe.Pointer(&t.old))
+}
+
+// size returns the width and height of the terminal in characters.
+func (t *terminal) size() (int, int, error) {
+	var ws struct {
+		Row, Col, Xpixel, Ypixel uint16
+	}
+	if err := ioctl(os.Stdout.Fd(), syscall.TIOCGWINSZ, unsafe.Pointer(&ws)); err != nil {
+		return 0, 0, err
+	}
+	return int(ws.Col), int(ws.Row), nil
+}
+
+// notifyResize sends on c whenever the terminal is resized.
+func (t *terminal) notifyResize(c chan<- os.Signal) {
+	signal.Notify(c, syscall.SIGWINCH)
+}
+
+func ioctl(fd, request uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
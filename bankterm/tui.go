@@ -0,0 +1,514 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/sjwhitworth/gomondo"
+	"github.com/sjwhitworth/gomondo/internal/mondoutil"
+)
+
+var (
+	errNotTerminal = fmt.Errorf("bankterm tui must be run in a terminal")
+
+	// Returned from a page of new transactions when the browser has quit.
+	errPollStopped = fmt.Errorf("polling stopped")
+)
+
+// Keys recognised by the browser, other than printable characters.
+const (
+	keyUp = iota + 0x110000
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+	keyEnter
+	keyEscape
+	keyBackspace
+	keyCtrlC
+)
+
+func tuiCmd(args []string) error {
+	var c commonFlags
+	fs := newFlagSet("tui", &c)
+	interval := fs.Duration("interval", 30*time.Second, "check for new transactions this often; 0 disables live updates")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	client, err := connect(&c)
+	if err != nil {
+		return err
+	}
+
+	ac, err := selectAccount(client, c.account)
+	if err != nil {
+		return err
+	}
+
+	var transactions []mondo.Transaction
+	err = eachPage(client, ac.ID, time.Time{}, time.Time{}, 0, func(page []mondo.Transaction) error {
+		transactions = append(transactions, page...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	b := &browser{account: ac.Description}
+	b.add(transactions)
+
+	term, err := openTerminal()
+	if err != nil {
+		return err
+	}
+	defer term.restore()
+
+	// Draw on the alternate screen, so the shell's scrollback is left as it was.
+	fmt.Fprint(os.Stdout, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(os.Stdout, "\x1b[?25h\x1b[?1049l")
+
+	keys := make(chan rune)
+	go readKeys(os.Stdin, keys)
+
+	resized := make(chan os.Signal, 1)
+	term.notifyResize(resized)
+
+	updates := make(chan []mondo.Transaction)
+	done := make(chan struct{})
+	defer close(done)
+	if *interval > 0 && !c.offline {
+		go poll(client, ac.ID, b.newestID(), *interval, updates, done)
+	}
+
+	for {
+		b.width, b.height, err = term.size()
+		if err != nil || b.width <= 0 || b.height <= 0 {
+			b.width, b.height = 80, 24
+		}
+
+		var buf bytes.Buffer
+		b.render(&buf)
+		os.Stdout.Write(buf.Bytes())
+
+		select {
+		case k, ok := <-keys:
+			if !ok || b.handleKey(k) {
+				return nil
+			}
+		case page := <-updates:
+			b.add(page)
+			b.status = fmt.Sprintf("%v new transaction(s) at %v", len(page), time.Now().Format("15:04:05"))
+		case <-resized:
+		}
+	}
+}
+
+// poll sends transactions created after lastId on updates as they appear, until done is closed.
+func poll(client mondo.Client, accountId, lastId string, interval time.Duration, updates chan<- []mondo.Transaction, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-done:
+			return
+		}
+
+		err := mondo.EachPage(client, accountId, lastId, "", 0, func(page []mondo.Transaction) error {
+			lastId = page[len(page)-1].ID
+			select {
+			case updates <- page:
+				return nil
+			case <-done:
+				return errPollStopped
+			}
+		})
+		if err == errPollStopped {
+			return
+		}
+		if err != nil {
+			slog.Debug("Failed to check for new transactions", "error", err)
+		}
+	}
+}
+
+// readKeys decodes key presses from r, including the escape sequences sent by arrow and paging keys, and sends them on keys. keys is closed when r is.
+func readKeys(r io.Reader, keys chan<- rune) {
+	defer close(keys)
+
+	sequences := map[string]rune{
+		"\x1b[A": keyUp, "\x1bOA": keyUp,
+		"\x1b[B": keyDown, "\x1bOB": keyDown,
+		"\x1b[5~": keyPageUp, "\x1b[6~": keyPageDown,
+		"\x1b[H": keyHome, "\x1bOH": keyHome, "\x1b[1~": keyHome,
+		"\x1b[F": keyEnd, "\x1bOF": keyEnd, "\x1b[4~": keyEnd,
+	}
+
+	buf := make([]byte, 64)
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			return
+		}
+
+		in := string(buf[:n])
+		if k, ok := sequences[in]; ok {
+			keys <- k
+			continue
+		}
+
+		for _, ch := range in {
+			switch ch {
+			case '\r', '\n':
+				keys <- keyEnter
+			case 0x1b:
+				// A lone escape; the rest of an unrecognised sequence is dropped.
+				keys <- keyEscape
+				in = ""
+			case 0x7f, 0x08:
+				keys <- keyBackspace
+			case 0x03:
+				keys <- keyCtrlC
+			default:
+				if ch >= ' ' {
+					keys <- ch
+				}
+			}
+			if in == "" {
+				break
+			}
+		}
+	}
+}
+
+// browser is the state of the transaction browser: every transaction newest first, the ones matching the filter, and which is selected.
+type browser struct {
+	account string
+	all     []mondo.Transaction
+	seen    map[string]bool
+
+	filter    string
+	searching bool
+	query     string
+	visible   []int
+
+	cursor, offset int
+	width, height  int
+	status         string
+}
+
+// add merges transactions, in the order the API returns them, into the list. The selection stays on the same transaction.
+func (b *browser) add(txs []mondo.Transaction) {
+	if b.seen == nil {
+		b.seen = map[string]bool{}
+	}
+
+	selected := b.selected()
+	for _, tx := range txs {
+		if b.seen[tx.ID] {
+			continue
+		}
+		b.seen[tx.ID] = true
+		b.all = append(b.all, tx)
+	}
+	sort.SliceStable(b.all, func(i, j int) bool { return b.all[i].Created > b.all[j].Created })
+
+	b.refilter(selected)
+}
+
+func (b *browser) newestID() string {
+	if len(b.all) == 0 {
+		return ""
+	}
+	return b.all[0].ID
+}
+
+// selected returns the ID of the selected transaction, or the empty string if none is.
+func (b *browser) selected() string {
+	if b.cursor < len(b.visible) {
+		return b.all[b.visible[b.cursor]].ID
+	}
+	return ""
+}
+
+// refilter recomputes the visible transactions, keeping the selection on id if it is still visible.
+func (b *browser) refilter(id string) {
+	b.visible = b.visible[:0]
+	b.cursor = 0
+	for i, tx := range b.all {
+		if !matches(tx, b.filter) {
+			continue
+		}
+		if tx.ID == id {
+			b.cursor = len(b.visible)
+		}
+		b.visible = append(b.visible, i)
+	}
+}
+
+// matches reports whether the merchant, description or category of tx contains filter, ignoring case.
+func matches(tx mondo.Transaction, filter string) bool {
+	if filter == "" {
+		return true
+	}
+	filter = strings.ToLower(filter)
+	for _, field := range []string{tx.Merchant.Name, tx.Description, tx.Category} {
+		if strings.Contains(strings.ToLower(field), filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleKey applies a key press, and reports whether the browser should quit.
+func (b *browser) handleKey(k rune) bool {
+	if k == keyCtrlC {
+		return true
+	}
+
+	if b.searching {
+		switch k {
+		case keyEnter:
+			b.searching = false
+		case keyEscape:
+			b.searching = false
+			b.query = b.filter
+		case keyBackspace:
+			if r := []rune(b.query); len(r) > 0 {
+				b.query = string(r[:len(r)-1])
+			}
+		default:
+			if k < keyUp {
+				b.query += string(k)
+			}
+		}
+
+		// Filter as the query is typed.
+		if b.query != b.filter {
+			b.filter = b.query
+			b.refilter(b.selected())
+		}
+		return false
+	}
+
+	page := b.listHeight()
+	switch k {
+	case 'q':
+		return true
+	case '/':
+		b.searching = true
+		b.query = b.filter
+	case keyEscape:
+		if b.filter != "" {
+			selected := b.selected()
+			b.filter, b.query = "", ""
+			b.refilter(selected)
+		}
+	case keyUp, 'k':
+		b.cursor--
+	case keyDown, 'j':
+		b.cursor++
+	case keyPageUp:
+		b.cursor -= page
+	case keyPageDown, ' ':
+		b.cursor += page
+	case keyHome, 'g':
+		b.cursor = 0
+	case keyEnd, 'G':
+		b.cursor = len(b.visible) - 1
+	}
+
+	if b.cursor >= len(b.visible) {
+		b.cursor = len(b.visible) - 1
+	}
+	if b.cursor < 0 {
+		b.cursor = 0
+	}
+	return false
+}
+
+// detailHeight is the number of rows given to the detail pane.
+func (b *browser) detailHeight() int {
+	h := b.height / 2
+	if h > 14 {
+		h = 14
+	}
+	return h
+}
+
+// listHeight is the number of rows given to the list, after the title, detail pane, separator and status line.
+func (b *browser) listHeight() int {
+	h := b.height - b.detailHeight() - 3
+	if h < 1 {
+		h = 1
+	}
+	return h
+}
+
+// render draws the whole screen to w.
+func (b *browser) render(w io.Writer) {
+	fmt.Fprint(w, "\x1b[H\x1b[2J")
+
+	title := fmt.Sprintf(" %v: %v transactions", b.account, len(b.all))
+	if b.filter != "" {
+		title += fmt.Sprintf(", %v matching %q", len(b.visible), b.filter)
+	}
+	b.line(w, "\x1b[7m", title)
+
+	// Scroll the list so the cursor is always on screen.
+	height := b.listHeight()
+	if b.cursor < b.offset {
+		b.offset = b.cursor
+	}
+	if b.cursor >= b.offset+height {
+		b.offset = b.cursor - height + 1
+	}
+
+	for row := 0; row < height; row++ {
+		i := b.offset + row
+		if i >= len(b.visible) {
+			b.line(w, "", "")
+			continue
+		}
+
+		style := ""
+		if i == b.cursor {
+			style = "\x1b[7m"
+		}
+		b.line(w, style, b.summary(b.all[b.visible[i]]))
+	}
+
+	b.line(w, "", strings.Repeat("─", b.width))
+
+	var detail []string
+	if b.cursor < len(b.visible) {
+		detail = details(b.all[b.visible[b.cursor]])
+	}
+	for row := 0; row < b.detailHeight(); row++ {
+		text := ""
+		if row < len(detail) {
+			text = detail[row]
+		}
+		b.line(w, "", text)
+	}
+
+	switch {
+	case b.searching:
+		fmt.Fprintf(w, "\x1b[1m/%v\x1b[0m█", b.query)
+	case b.status != "":
+		fmt.Fprint(w, runewidth.Truncate(" "+b.status+"  (q to quit)", b.width, ""))
+	default:
+		fmt.Fprint(w, runewidth.Truncate(" ↑/↓ move  PgUp/PgDn page  / search  esc clear search  q quit", b.width, ""))
+	}
+}
+
+// line writes text in style, truncated or padded to the width of the screen.
+func (b *browser) line(w io.Writer, style, text string) {
+	text = runewidth.Truncate(text, b.width, "…")
+	text = runewidth.FillRight(text, b.width)
+	fmt.Fprintf(w, "%v%v\x1b[0m\r\n", style, text)
+}
+
+// summary is the line shown in the list for a transaction.
+func (b *browser) summary(tx mondo.Transaction) string {
+	created := tx.Created
+	if t, err := time.Parse(time.RFC3339, tx.Created); err == nil {
+		created = t.Local().Format("2006-01-02 15:04")
+	}
+
+	name := merchantName(tx)
+	if name == "" {
+		name = tx.Description
+	}
+
+	amount := mondoutil.FormatAmount(tx.Amount, tx.Currency)
+	if tx.Settled == "" {
+		amount += "*"
+	}
+
+	// Leave room for the date, amount and category columns, and the gaps between them.
+	nameWidth := b.width - 1 - 16 - 2 - 2 - 12 - 2 - 14
+	if nameWidth < 10 {
+		nameWidth = 10
+	}
+	name = runewidth.FillRight(runewidth.Truncate(name, nameWidth, "…"), nameWidth)
+	return fmt.Sprintf(" %-16v  %v  %12v  %v", created, name, amount, tx.Category)
+}
+
+// details are the lines of the detail pane for a transaction.
+func details(tx mondo.Transaction) []string {
+	settled := tx.Settled
+	if settled == "" {
+		settled = "pending"
+	}
+
+	lines := []string{
+		fmt.Sprintf(" %v  (%v)", tx.Description, tx.ID),
+		fmt.Sprintf(" Amount:   %v, leaving a balance of %v", mondoutil.FormatAmount(tx.Amount, tx.Currency), mondoutil.FormatAmount(tx.AccountBalance, tx.Currency)),
+		fmt.Sprintf(" Created:  %v   Settled: %v", tx.Created, settled),
+		fmt.Sprintf(" Category: %v", tx.Category),
+	}
+
+	if m := tx.Merchant; m.Name != "" {
+		lines = append(lines, " Merchant: "+strings.Join(nonEmpty(m.Emoji, m.Name), " "))
+
+		address := m.Address.Formatted
+		if address == "" {
+			address = strings.Join(nonEmpty(m.Address.Address, m.Address.City, m.Address.Postcode, m.Address.Country), ", ")
+		}
+		if address != "" {
+			lines = append(lines, " Address:  "+address)
+		}
+	}
+
+	if tx.Notes != "" {
+		lines = append(lines, " Notes:    "+tx.Notes)
+	}
+
+	var keys []string
+	for k := range tx.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf(" Metadata: %v = %v", k, tx.Metadata[k]))
+	}
+
+	for _, a := range tx.Attachments {
+		lines = append(lines, " Attached: "+attachmentURL(a))
+	}
+
+	return lines
+}
+
+// attachmentURL returns the file URL of an attachment, as decoded from a transaction.
+func attachmentURL(a interface{}) string {
+	if m, ok := a.(map[string]interface{}); ok {
+		if u, ok := m["file_url"].(string); ok {
+			return u
+		}
+	}
+	b, _ := json.Marshal(a)
+	return string(b)
+}
+
+func nonEmpty(values ...string) []string {
+	var out []string
+	for _, v := range values {
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
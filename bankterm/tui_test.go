@@ -0,0 +1,162 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/sjwhitworth/gomondo/mondotest"
+	"github.com/stretchr/testify/assert"
+)
+
+func browserTransactions() []mondo.Transaction {
+	return []mondo.Transaction{
+		{ID: "tx_1", Amount: -350, Created: "2015-08-22T09:00:00Z", Description: "PRET A MANGER", Merchant: mondo.Merchant{Name: "Pret A Manger"}, Category: "eating_out"},
+		{ID: "tx_2", Amount: -1200, Created: "2015-08-23T09:00:00Z", Description: "TFL.GOV.UK", Category: "transport"},
+		{ID: "tx_3", Amount: -899, Created: "2015-08-24T09:00:00Z", Description: "NETFLIX.COM", Merchant: mondo.Merchant{Name: "Netflix"}, Category: "entertainment"},
+	}
+}
+
+func visibleIDs(b *browser) []string {
+	var ids []string
+	for _, i := range b.visible {
+		ids = append(ids, b.all[i].ID)
+	}
+	return ids
+}
+
+func TestBrowserAdd(t *testing.T) {
+	b := &browser{height: 24}
+	assert.Equal(t, "", b.newestID())
+
+	txs := browserTransactions()
+	b.add(txs[:2])
+	assert.Equal(t, []string{"tx_2", "tx_1"}, visibleIDs(b))
+	assert.Equal(t, "tx_2", b.newestID())
+
+	// Select the older transaction, then merge in a newer one and a repeat.
+	b.handleKey(keyDown)
+	assert.Equal(t, "tx_1", b.selected())
+
+	b.add([]mondo.Transaction{txs[1], txs[2]})
+	assert.Equal(t, []string{"tx_3", "tx_2", "tx_1"}, visibleIDs(b))
+	assert.Equal(t, "tx_3", b.newestID())
+	assert.Equal(t, "tx_1", b.selected())
+}
+
+func TestBrowserKeys(t *testing.T) {
+	b := &browser{height: 24}
+	b.add(browserTransactions())
+
+	keys := func(ks ...rune) {
+		for _, k := range ks {
+			assert.False(t, b.handleKey(k))
+		}
+	}
+
+	keys(keyDown, 'j')
+	assert.Equal(t, "tx_1", b.selected())
+
+	// Moving past either end stays on the last or first transaction.
+	keys(keyDown, keyPageDown)
+	assert.Equal(t, "tx_1", b.selected())
+	keys('k')
+	assert.Equal(t, "tx_2", b.selected())
+	keys(keyPageUp, keyUp)
+	assert.Equal(t, "tx_3", b.selected())
+	keys('G')
+	assert.Equal(t, "tx_1", b.selected())
+	keys(keyHome)
+	assert.Equal(t, "tx_3", b.selected())
+
+	// Searching filters as the query is typed, matching merchant, description or category.
+	keys('/', 'p', 'r', 'x')
+	assert.True(t, b.searching)
+	assert.Empty(t, visibleIDs(b))
+	assert.Equal(t, "", b.selected())
+	keys(keyBackspace)
+	assert.Equal(t, []string{"tx_1"}, visibleIDs(b))
+	keys(keyEnter)
+	assert.False(t, b.searching)
+	assert.Equal(t, "pr", b.filter)
+
+	// q is part of a query while searching, and escape stops searching but keeps the filter.
+	keys('/', 'q')
+	assert.Equal(t, "prq", b.filter)
+	keys(keyEscape)
+	assert.False(t, b.searching)
+	assert.Equal(t, "prq", b.filter)
+
+	keys('/', keyBackspace, keyBackspace, keyBackspace, 't', 'r', 'a', 'n', 's', keyEnter)
+	assert.Equal(t, []string{"tx_2"}, visibleIDs(b))
+
+	// Escape clears the filter and keeps the selection.
+	keys(keyEscape)
+	assert.Equal(t, "", b.filter)
+	assert.Equal(t, []string{"tx_3", "tx_2", "tx_1"}, visibleIDs(b))
+	assert.Equal(t, "tx_2", b.selected())
+
+	assert.True(t, b.handleKey('q'))
+	b.handleKey('/')
+	assert.True(t, b.handleKey(keyCtrlC))
+}
+
+func TestPoll(t *testing.T) {
+	fake := pagedClient(mondo.MaxPageSize + 5)
+	updates := make(chan []mondo.Transaction)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		poll(fake, "acc_1", "", time.Millisecond, updates, done)
+		close(stopped)
+	}()
+
+	// Every new transaction is sent, a page at a time.
+	first := <-updates
+	assert.Equal(t, mondo.MaxPageSize, len(first))
+	second := <-updates
+	assert.Equal(t, []string{"tx_100", "tx_101", "tx_102", "tx_103", "tx_104"}, ids(second))
+
+	_, since, _, _ := fake.TransactionsArgsForCall(1)
+	assert.Equal(t, "tx_099", since)
+
+	close(done)
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("poll did not stop")
+	}
+}
+
+func TestPollStopsWhileSending(t *testing.T) {
+	fake := &mondotest.FakeClient{}
+	fake.TransactionsReturns([]mondo.Transaction{{ID: "tx_1"}}, nil)
+
+	// Nothing reads updates, as when the browser has quit.
+	updates := make(chan []mondo.Transaction)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		poll(fake, "acc_1", "", time.Millisecond, updates, done)
+		close(stopped)
+	}()
+
+	for fake.TransactionsCallCount() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(done)
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("poll did not stop")
+	}
+}
+
+func ids(txs []mondo.Transaction) []string {
+	var ids []string
+	for _, tx := range txs {
+		ids = append(ids, tx.ID)
+	}
+	return ids
+}
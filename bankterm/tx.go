@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/sjwhitworth/gomondo"
+	"github.com/sjwhitworth/gomondo/internal/mondoutil"
+)
+
+func tx(args []string) error {
+	if len(args) == 0 {
+		return usageError{"usage: bankterm tx list|show"}
+	}
+
+	switch args[0] {
+	case "list":
+		return txList(args[1:])
+	case "show":
+		return txShow(args[1:])
+	}
+
+	return usageError{fmt.Sprintf("unknown tx command %q", args[0])}
+}
+
+func txList(args []string) error {
+	var c commonFlags
+	fs := newFlagSet("tx list", &c)
+	fs.Lookup("format").Usage = "output format: table, csv, json or jsonl"
+	limit := fs.Int("limit", 100, "maximum number of transactions to list; 0 lists them all")
+	sinceFlag := fs.String("since", "", "only list transactions created at or after this date (YYYY-MM-DD or RFC3339)")
+	beforeFlag := fs.String("before", "", "only list transactions created before this date (YYYY-MM-DD or RFC3339)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	since, err := parseTime(*sinceFlag)
+	if err != nil {
+		return err
+	}
+
+	before, err := parseTime(*beforeFlag)
+	if err != nil {
+		return err
+	}
+
+	w, err := newTransactionWriter(c.format, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	client, err := connect(&c)
+	if err != nil {
+		return err
+	}
+
+	ac, err := selectAccount(client, c.account)
+	if err != nil {
+		return err
+	}
+
+	// Write each page as it arrives, so that full history exports are streamed rather than buffered.
+	found := false
+	err = eachPage(client, ac.ID, since, before, *limit, func(page []mondo.Transaction) error {
+		found = true
+		return w.Write(page)
+	})
+	if err != nil {
+		return err
+	}
+
+	if !found && c.format == "table" {
+		slog.Warn("No transactions found. Sorry!")
+	}
+
+	return w.Close()
+}
+
+func txShow(args []string) error {
+	var c commonFlags
+	fs := newFlagSet("tx show", &c)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return usageError{"usage: bankterm tx show [flags] TRANSACTION_ID"}
+	}
+
+	client, err := connect(&c)
+	if err != nil {
+		return err
+	}
+
+	ac, err := selectAccount(client, c.account)
+	if err != nil {
+		return err
+	}
+
+	t, err := client.TransactionByID(ac.ID, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	switch c.format {
+	case "json":
+		return writeJSON(t)
+	case "table":
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.AppendBulk([][]string{
+			{"ID", t.ID},
+			{"Created", t.Created},
+			{"Settled", t.Settled},
+			{"Description", t.Description},
+			{"Amount", mondoutil.FormatAmount(t.Amount, t.Currency)},
+			{"Balance", mondoutil.FormatAmount(t.AccountBalance, t.Currency)},
+			{"Category", t.Category},
+			{"Merchant", t.Merchant.Name},
+			{"Address", t.Merchant.Address.Formatted},
+			{"Notes", t.Notes},
+		})
+		table.Render()
+		return nil
+	}
+
+	return usageError{fmt.Sprintf("unknown format %q", c.format)}
+}
+
+// eachPage pages through the transactions of an account created within [since, before), calling fn with each page. A zero since or before leaves that side of the range open. At most limit transactions are fetched, unless limit is 0.
+func eachPage(client mondo.Client, accountId string, since, before time.Time, limit int, fn func([]mondo.Transaction) error) error {
+	var sinceParam, beforeParam string
+	if !since.IsZero() {
+		sinceParam = since.Format(time.RFC3339)
+	}
+	if !before.IsZero() {
+		beforeParam = before.Format(time.RFC3339)
+	}
+
+	return mondo.EachPage(client, accountId, sinceParam, beforeParam, limit, fn)
+}
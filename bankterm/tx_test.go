@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/sjwhitworth/gomondo/mondotest"
+	"github.com/stretchr/testify/assert"
+)
+
+// pagedClient returns a FakeClient holding n transactions, which pages through them by ID as the API does.
+func pagedClient(n int) *mondotest.FakeClient {
+	var all []mondo.Transaction
+	for i := 0; i < n; i++ {
+		all = append(all, mondo.Transaction{ID: fmt.Sprintf("tx_%03d", i), Amount: -i})
+	}
+
+	fake := &mondotest.FakeClient{}
+	fake.TransactionsCalls(func(accountId, since, before string, limit int) ([]mondo.Transaction, error) {
+		start := 0
+		for i, t := range all {
+			if t.ID == since {
+				start = i + 1
+			}
+		}
+		end := start + limit
+		if end > len(all) {
+			end = len(all)
+		}
+		return all[start:end], nil
+	})
+	return fake
+}
+
+func TestEachPage(t *testing.T) {
+	tests := []struct {
+		name     string
+		total    int
+		limit    int
+		want     int
+		requests []int
+	}{
+		{"all", 250, 0, 250, []int{100, 100, 100}},
+		{"limited", 250, 150, 150, []int{100, 50}},
+		{"limit on a page boundary", 250, 200, 200, []int{100, 100}},
+		{"fewer than the limit", 30, 100, 30, []int{100}},
+		{"full last page", 200, 0, 200, []int{100, 100, 100}},
+		{"none", 0, 0, 0, []int{100}},
+	}
+
+	for _, test := range tests {
+		fake := pagedClient(test.total)
+
+		var ids []string
+		err := eachPage(fake, "acc_1", time.Time{}, time.Time{}, test.limit, func(page []mondo.Transaction) error {
+			assert.NotEmpty(t, page, test.name)
+			for _, t := range page {
+				ids = append(ids, t.ID)
+			}
+			return nil
+		})
+		assert.NoError(t, err, test.name)
+		assert.Len(t, ids, test.want, test.name)
+
+		// Each transaction is seen once, in order.
+		for i, id := range ids {
+			assert.Equal(t, fmt.Sprintf("tx_%03d", i), id, test.name)
+		}
+
+		var requests []int
+		for i := 0; i < fake.TransactionsCallCount(); i++ {
+			_, _, _, limit := fake.TransactionsArgsForCall(i)
+			requests = append(requests, limit)
+		}
+		assert.Equal(t, test.requests, requests, test.name)
+	}
+}
+
+func TestEachPageParams(t *testing.T) {
+	fake := pagedClient(150)
+	since := time.Date(2015, 8, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2015, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, eachPage(fake, "acc_1", since, before, 0, func([]mondo.Transaction) error { return nil }))
+	assert.Equal(t, 2, fake.TransactionsCallCount())
+
+	accountId, sinceParam, beforeParam, _ := fake.TransactionsArgsForCall(0)
+	assert.Equal(t, "acc_1", accountId)
+	assert.Equal(t, "2015-08-01T00:00:00Z", sinceParam)
+	assert.Equal(t, "2015-09-01T00:00:00Z", beforeParam)
+
+	// Later pages start after the last transaction seen.
+	_, sinceParam, beforeParam, _ = fake.TransactionsArgsForCall(1)
+	assert.Equal(t, "tx_099", sinceParam)
+	assert.Equal(t, "2015-09-01T00:00:00Z", beforeParam)
+}
+
+func TestEachPageErrors(t *testing.T) {
+	fake := pagedClient(250)
+	next := fake.TransactionsStub
+	fake.TransactionsCalls(func(accountId, since, before string, limit int) ([]mondo.Transaction, error) {
+		if since != "" {
+			return nil, mondo.ErrUnauthenticatedRequest
+		}
+		return next(accountId, since, before, limit)
+	})
+	err := eachPage(fake, "acc_1", time.Time{}, time.Time{}, 0, func([]mondo.Transaction) error { return nil })
+	assert.Equal(t, mondo.ErrUnauthenticatedRequest, err)
+
+	// An error from fn stops paging.
+	fake = pagedClient(250)
+	stop := fmt.Errorf("stop")
+	err = eachPage(fake, "acc_1", time.Time{}, time.Time{}, 0, func([]mondo.Transaction) error { return stop })
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, fake.TransactionsCallCount())
+}
@@ -0,0 +1,95 @@
+// Package budget tracks monthly spending per category against budgets, and warns in the Mondo feed when a budget is running out.
+//
+// Budgets are usually loaded from a JSON file:
+//
+//	{
+//	  "budgets": [
+//	    {"category": "eating_out", "limit": 20000},
+//	    {"category": "groceries", "limit": 30000, "thresholds": [50, 90, 100]}
+//	  ]
+//	}
+//
+// Limits are in minor units, such as pence. Thresholds are percentages of the limit, and default to DefaultThresholds.
+package budget
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+var (
+	// The percentages of a budget at which alerts are posted, unless a budget sets its own.
+	DefaultThresholds = []int{80, 100}
+
+	// The image shown alongside alerts in the feed, unless the config sets its own.
+	DefaultImageURL = "https://blog.golang.org/gopher/gopher.png"
+)
+
+// Budget is a monthly spending limit for a category.
+type Budget struct {
+	Category   string `json:"category"`
+	Limit      int    `json:"limit"`
+	Thresholds []int  `json:"thresholds,omitempty"`
+}
+
+// Config is a set of budgets, and how to alert on them.
+type Config struct {
+	Budgets []Budget `json:"budgets"`
+
+	// ImageURL is shown alongside alerts in the feed.
+	ImageURL string `json:"image_url,omitempty"`
+}
+
+// LoadConfig reads a Config from a JSON file.
+func LoadConfig(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseConfig(f)
+}
+
+// ParseConfig reads a Config from JSON, checks it and fills in defaults.
+func ParseConfig(r io.Reader) (*Config, error) {
+	cfg := &Config{}
+	if err := json.NewDecoder(r).Decode(cfg); err != nil {
+		return nil, fmt.Errorf("invalid budget config: %v", err)
+	}
+
+	seen := map[string]bool{}
+	for i := range cfg.Budgets {
+		b := &cfg.Budgets[i]
+		if b.Category == "" {
+			return nil, fmt.Errorf("budget %v has no category", i)
+		}
+		if seen[b.Category] {
+			return nil, fmt.Errorf("category %v has more than one budget", b.Category)
+		}
+		seen[b.Category] = true
+
+		if b.Limit <= 0 {
+			return nil, fmt.Errorf("budget for %v must have a positive limit", b.Category)
+		}
+
+		if len(b.Thresholds) == 0 {
+			b.Thresholds = append([]int(nil), DefaultThresholds...)
+		}
+		for _, t := range b.Thresholds {
+			if t <= 0 {
+				return nil, fmt.Errorf("budget for %v has invalid threshold %v", b.Category, t)
+			}
+		}
+		sort.Ints(b.Thresholds)
+	}
+
+	if cfg.ImageURL == "" {
+		cfg.ImageURL = DefaultImageURL
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,148 @@
+package budget
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/sjwhitworth/gomondo/mondotest"
+	"github.com/stretchr/testify/assert"
+)
+
+const config = `{
+  "budgets": [
+    {"category": "eating_out", "limit": 10000},
+    {"category": "groceries", "limit": 20000, "thresholds": [100, 50]}
+  ]
+}`
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig(strings.NewReader(config))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{80, 100}, cfg.Budgets[0].Thresholds)
+	assert.Equal(t, []int{50, 100}, cfg.Budgets[1].Thresholds)
+	assert.Equal(t, DefaultImageURL, cfg.ImageURL)
+
+	_, err = ParseConfig(strings.NewReader(`{"budgets": [{"category": "eating_out"}]}`))
+	assert.Error(t, err)
+
+	_, err = ParseConfig(strings.NewReader(`{"budgets": [{"category": "a", "limit": 1}, {"category": "a", "limit": 2}]}`))
+	assert.Error(t, err)
+}
+
+func TestEngine(t *testing.T) {
+	cfg, err := ParseConfig(strings.NewReader(config))
+	assert.NoError(t, err)
+
+	// Spending from before the engine started is fetched from the API.
+	fake := &mondotest.FakeClient{}
+	fake.TransactionsReturns([]mondo.Transaction{
+		{ID: "tx_1", Amount: -5000, Category: "eating_out", Created: "2015-08-02T12:00:00Z"},
+		{ID: "tx_2", Amount: 20000, Category: "mondo", Created: "2015-08-02T12:00:00Z", IsLoad: true},
+	}, nil)
+
+	e := NewEngine(fake, "acc_1", cfg, NewMemoryStore())
+	e.Location = time.UTC
+
+	assert.NoError(t, e.Observe(mondo.Transaction{ID: "tx_3", Amount: -2000, Category: "eating_out", Created: "2015-08-10T12:00:00Z"}))
+	assert.Equal(t, 0, fake.CreateFeedItemCallCount())
+	assert.Equal(t, 7000, e.Spent("eating_out", time.Date(2015, 8, 31, 0, 0, 0, 0, time.UTC)))
+
+	accountId, since, before, _ := fake.TransactionsArgsForCall(0)
+	assert.Equal(t, "acc_1", accountId)
+	assert.Equal(t, "2015-08-01T00:00:00Z", since)
+	assert.Equal(t, "2015-09-01T00:00:00Z", before)
+
+	// Crossing 80% alerts once.
+	assert.NoError(t, e.Observe(mondo.Transaction{ID: "tx_4", Amount: -1500, Category: "eating_out", Created: "2015-08-11T12:00:00Z"}))
+	assert.NoError(t, e.Observe(mondo.Transaction{ID: "tx_4", Amount: -1500, Category: "eating_out", Created: "2015-08-11T12:00:00Z"}))
+	assert.Equal(t, 1, fake.CreateFeedItemCallCount())
+	accountId, title, _, bg, _, _, body := fake.CreateFeedItemArgsForCall(0)
+	assert.Equal(t, "acc_1", accountId)
+	assert.Equal(t, "You've used 80% of your eating out budget", title)
+	assert.Equal(t, WarningBackground, bg)
+	assert.Equal(t, "£85.00 of £100.00 spent in August 2015.", body)
+
+	// Crossing 100% alerts again, but only once.
+	assert.NoError(t, e.Observe(mondo.Transaction{ID: "tx_5", Amount: -1500, Category: "eating_out", Created: "2015-08-12T12:00:00Z"}))
+	assert.NoError(t, e.Observe(mondo.Transaction{ID: "tx_6", Amount: -100, Category: "eating_out", Created: "2015-08-13T12:00:00Z"}))
+	assert.Equal(t, 2, fake.CreateFeedItemCallCount())
+	_, title, _, bg, _, _, _ = fake.CreateFeedItemArgsForCall(1)
+	assert.Equal(t, "You've spent your eating out budget", title)
+	assert.Equal(t, ExceededBackground, bg)
+
+	// A new month starts afresh.
+	fake.TransactionsReturns(nil, nil)
+	assert.NoError(t, e.Observe(mondo.Transaction{ID: "tx_7", Amount: -9000, Category: "eating_out", Created: "2015-09-01T12:00:00Z"}))
+	assert.Equal(t, 3, fake.CreateFeedItemCallCount())
+}
+
+func TestEngineJumpsToHighestThreshold(t *testing.T) {
+	cfg, err := ParseConfig(strings.NewReader(config))
+	assert.NoError(t, err)
+
+	fake := &mondotest.FakeClient{}
+	fake.TransactionsReturns([]mondo.Transaction{
+		{ID: "tx_1", Amount: -25000, Category: "groceries", Created: "2015-08-02T12:00:00Z"},
+	}, nil)
+
+	dir, err := ioutil.TempDir("", "budget")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := OpenFileStore(filepath.Join(dir, "state.json"))
+	assert.NoError(t, err)
+
+	e := NewEngine(fake, "acc_1", cfg, store)
+	e.Location = time.UTC
+	assert.NoError(t, e.Poll(time.Date(2015, 8, 20, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 1, fake.CreateFeedItemCallCount())
+
+	// Alerts are remembered across restarts.
+	store, err = OpenFileStore(filepath.Join(dir, "state.json"))
+	assert.NoError(t, err)
+	e = NewEngine(fake, "acc_1", cfg, store)
+	e.Location = time.UTC
+	assert.NoError(t, e.Poll(time.Date(2015, 8, 21, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 1, fake.CreateFeedItemCallCount())
+}
+
+func TestEngineServeHTTP(t *testing.T) {
+	cfg, err := ParseConfig(strings.NewReader(config))
+	assert.NoError(t, err)
+
+	fake := &mondotest.FakeClient{}
+	e := NewEngine(fake, "acc_1", cfg, NewMemoryStore())
+	e.Location = time.UTC
+
+	event := `{"type": "transaction.created", "data": {"id": "tx_1", "amount": -9000, "category": "eating_out", "created": "2015-08-10T12:00:00Z"}}`
+	post := func(target, body string) int {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest("POST", target, strings.NewReader(body)))
+		return w.Code
+	}
+
+	// Without a token, nothing is accepted.
+	assert.Equal(t, http.StatusForbidden, post("/?token=", event))
+
+	e.Token = "s3cret"
+	assert.Equal(t, http.StatusForbidden, post("/", event))
+	assert.Equal(t, http.StatusForbidden, post("/?token=guess", event))
+
+	large := `{"type": "transaction.created", "padding": "` + strings.Repeat("x", maxEventSize) + `"}`
+	assert.Equal(t, http.StatusBadRequest, post("/?token=s3cret", large))
+
+	// Rejected events neither count towards budgets nor post alerts.
+	assert.Equal(t, 0, e.Spent("eating_out", time.Date(2015, 8, 31, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 0, fake.CreateFeedItemCallCount())
+
+	assert.Equal(t, http.StatusOK, post("/?token=s3cret", event))
+	assert.Equal(t, 9000, e.Spent("eating_out", time.Date(2015, 8, 31, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 1, fake.CreateFeedItemCallCount())
+}
@@ -0,0 +1,230 @@
+package budget
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/sjwhitworth/gomondo/internal/mondoutil"
+)
+
+// Feed item colours for alerts that warn of, and report, an exhausted budget.
+var (
+	WarningBackground  = "#FFF4D6"
+	ExceededBackground = "#FCE3E0"
+	AlertTitleColor    = "#333333"
+	AlertBodyColor     = "#555555"
+)
+
+// Engine evaluates an account's transactions against a Config, posting a feed item the first time spending in a category crosses each threshold in a calendar month. Transactions can be fed to it live from webhooks with Observe or ServeHTTP, or polled from the API with Poll. It is safe for concurrent use.
+type Engine struct {
+	// Location is the time zone that months are measured in. Defaults to time.Local.
+	Location *time.Location
+
+	// Token must be carried by webhook events in their token query parameter, so that no one else can post alerts or use up thresholds with made up transactions. ServeHTTP rejects every event while it is empty.
+	Token string
+
+	client    mondo.Client
+	accountId string
+	cfg       *Config
+	store     Store
+
+	mu sync.Mutex
+
+	// periods holds the spending seen in each month, keyed by transaction ID so that updates to a transaction replace it rather than counting it twice.
+	periods map[string]map[string]mondo.Transaction
+}
+
+// NewEngine returns an Engine for an account.
+func NewEngine(client mondo.Client, accountId string, cfg *Config, store Store) *Engine {
+	return &Engine{
+		Location:  time.Local,
+		client:    client,
+		accountId: accountId,
+		cfg:       cfg,
+		store:     store,
+		periods:   map[string]map[string]mondo.Transaction{},
+	}
+}
+
+// period returns the month a time falls in, such as 2015-08, and when that month starts.
+func (e *Engine) period(t time.Time) (string, time.Time) {
+	t = t.In(e.Location)
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, e.Location)
+	return start.Format("2006-01"), start
+}
+
+// load fetches every transaction in the month starting at start, so that spending which happened before the engine started is counted. Must be called with e.mu held.
+func (e *Engine) load(key string, start time.Time) error {
+	if _, ok := e.periods[key]; ok {
+		return nil
+	}
+
+	end := start.AddDate(0, 1, 0)
+	seen := map[string]mondo.Transaction{}
+	err := mondo.EachPage(e.client, e.accountId, start.Format(time.RFC3339), end.Format(time.RFC3339), 0, func(page []mondo.Transaction) error {
+		for _, tx := range page {
+			seen[tx.ID] = tx
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	e.periods[key] = seen
+	return nil
+}
+
+// Observe counts a transaction towards its month's budgets, and posts any alerts it triggers.
+func (e *Engine) Observe(tx mondo.Transaction) error {
+	created, err := time.Parse(time.RFC3339, tx.Created)
+	if err != nil {
+		return fmt.Errorf("transaction %v has invalid created time: %v", tx.ID, err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	key, start := e.period(created)
+	if err := e.load(key, start); err != nil {
+		return err
+	}
+
+	e.periods[key][tx.ID] = tx
+	return e.evaluate(key, start)
+}
+
+// Poll fetches the transactions for the month containing now from the API, and posts any alerts they trigger.
+func (e *Engine) Poll(now time.Time) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	key, start := e.period(now)
+	delete(e.periods, key)
+	if err := e.load(key, start); err != nil {
+		return err
+	}
+
+	return e.evaluate(key, start)
+}
+
+// Spent returns the amount spent in a category in the month containing t, as far as the engine knows.
+func (e *Engine) Spent(category string, t time.Time) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	key, _ := e.period(t)
+	return spent(e.periods[key], category)
+}
+
+// spent totals the spending in a category. Top ups and refunds are not spending.
+func spent(transactions map[string]mondo.Transaction, category string) int {
+	total := 0
+	for _, tx := range transactions {
+		if tx.Category == category && tx.Amount < 0 && !tx.IsLoad {
+			total -= tx.Amount
+		}
+	}
+	return total
+}
+
+// currencyOf returns the currency of the transactions, or the empty string, taken as GBP, if none say.
+func currencyOf(transactions map[string]mondo.Transaction) string {
+	for _, tx := range transactions {
+		if tx.Currency != "" {
+			return tx.Currency
+		}
+	}
+	return ""
+}
+
+// evaluate posts an alert for each threshold that spending in the month has crossed, unless one has been posted before. Must be called with e.mu held.
+func (e *Engine) evaluate(key string, start time.Time) error {
+	for _, b := range e.cfg.Budgets {
+		total := spent(e.periods[key], b.Category)
+
+		// Only alert on the highest threshold crossed, but remember the lower ones so they are not posted later.
+		var crossed []int
+		for _, t := range b.Thresholds {
+			if total*100 >= b.Limit*t {
+				crossed = append(crossed, t)
+			}
+		}
+		if len(crossed) == 0 {
+			continue
+		}
+
+		highest := crossed[len(crossed)-1]
+		alertKey := fmt.Sprintf("%v/%v/%v/%v", e.accountId, key, b.Category, highest)
+		alerted, err := e.store.Alerted(alertKey)
+		if err != nil {
+			return err
+		}
+		if alerted {
+			continue
+		}
+
+		if err := e.alert(b, highest, total, currencyOf(e.periods[key]), start); err != nil {
+			return err
+		}
+
+		for _, t := range crossed {
+			if err := e.store.MarkAlerted(fmt.Sprintf("%v/%v/%v/%v", e.accountId, key, b.Category, t)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (e *Engine) alert(b Budget, threshold, total int, currency string, start time.Time) error {
+	category := strings.Replace(b.Category, "_", " ", -1)
+
+	title := fmt.Sprintf("You've used %v%% of your %v budget", threshold, category)
+	background := WarningBackground
+	if threshold >= 100 {
+		title = fmt.Sprintf("You've spent your %v budget", category)
+		background = ExceededBackground
+	}
+
+	body := fmt.Sprintf("%v of %v spent in %v.", mondoutil.FormatAmount(total, currency), mondoutil.FormatAmount(b.Limit, currency), start.Format("January 2006"))
+	return e.client.CreateFeedItem(e.accountId, title, e.cfg.ImageURL, background, AlertBodyColor, AlertTitleColor, body)
+}
+
+// The largest webhook event accepted, which is far more than a transaction needs.
+const maxEventSize = 64 << 10
+
+// ServeHTTP receives Mondo webhook events, and observes the transactions they carry. Events must carry Token in their token query parameter, so the URL to register is such as https://example.com/?token=...
+func (e *Engine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if e.Token == "" || subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(e.Token)) != 1 {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	var event mondo.WebhookRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxEventSize)).Decode(&event); err != nil {
+		http.Error(w, "malformed event", http.StatusBadRequest)
+		return
+	}
+
+	if event.Type != mondo.EventTransactionCreated || event.Data == nil {
+		return
+	}
+
+	if err := e.Observe(*event.Data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,60 @@
+package budget
+
+import (
+	"sync"
+
+	"github.com/sjwhitworth/gomondo/internal/mondoutil"
+)
+
+// Store remembers which alerts have been posted, so that each is posted at most once.
+type Store interface {
+	Alerted(key string) (bool, error)
+	MarkAlerted(key string) error
+}
+
+// MemoryStore is a Store that forgets everything when the process exits.
+type MemoryStore struct {
+	mu   sync.Mutex
+	keys map[string]bool
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{keys: map[string]bool{}}
+}
+
+func (s *MemoryStore) Alerted(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys[key], nil
+}
+
+func (s *MemoryStore) MarkAlerted(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[key] = true
+	return nil
+}
+
+// FileStore is a Store persisted to a JSON file, so that alerts are not repeated across restarts.
+type FileStore struct {
+	keys *mondoutil.KeyStore
+}
+
+// OpenFileStore loads a FileStore from path. The file is created when the first alert is marked.
+func OpenFileStore(path string) (*FileStore, error) {
+	keys, err := mondoutil.OpenKeyStore(path)
+	if err != nil {
+		return nil, err
+	}
+	return &FileStore{keys: keys}, nil
+}
+
+func (s *FileStore) Alerted(key string) (bool, error) {
+	return s.keys.Has(key)
+}
+
+// MarkAlerted records key, and rewrites the file atomically.
+func (s *FileStore) MarkAlerted(key string) error {
+	return s.keys.Add(key)
+}
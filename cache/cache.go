@@ -0,0 +1,518 @@
+// Package cache keeps a local copy of Mondo accounts and transactions in plain JSON files, so that history can be read without the API.
+//
+// A Cache is kept up to date with Sync, which only fetches transactions newer than those already stored, and refetches recent ones that had not settled. Each account's transactions are kept in a JSON-lines log that a Sync only appends to, so that it writes what changed rather than the whole history. The Cache implements mondo.Client, so code written against the API, such as reports, can run offline against it. Calls that would change something through the API fail with ErrOffline.
+//
+//	c, err := cache.Open(dir, client)
+//	if err != nil {
+//	  return err
+//	}
+//
+//	result, err := c.Sync(accountId)
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sjwhitworth/gomondo"
+)
+
+var (
+	// How far back Sync looks for unsettled transactions to refresh, unless a Cache sets its own.
+	DefaultRefreshWindow = 14 * 24 * time.Hour
+
+	// Returned by calls that need the API, such as CreateFeedItem.
+	ErrOffline = fmt.Errorf("not available offline")
+
+	// Returned by Sync when the cache was opened without a client.
+	ErrNoClient = fmt.Errorf("cache has no client to sync from")
+)
+
+// SyncResult describes what a Sync changed.
+type SyncResult struct {
+	// Added is the number of transactions stored for the first time.
+	Added int `json:"added"`
+
+	// Updated is the number of stored transactions that were replaced with a newer version.
+	Updated int `json:"updated"`
+}
+
+// ledger is an account's transactions, in the order they were created, as read from its log.
+type ledger struct {
+	LastSync     time.Time
+	Transactions []mondo.Transaction
+
+	// lines is the number of entries in the log, and size the length of the log up to the end of the last whole entry.
+	lines int
+	size  int64
+}
+
+// entry is a line of a ledger's log. A transaction replaces any earlier entry with the same ID, and synced records when a Sync finished.
+type entry struct {
+	Transaction *mondo.Transaction `json:"transaction,omitempty"`
+	Synced      *time.Time         `json:"synced,omitempty"`
+}
+
+// Cache is a local store of accounts and transactions in a directory. It is safe for concurrent use, but not for use by several processes at once.
+type Cache struct {
+	// RefreshWindow is how far back Sync looks for unsettled transactions to refresh.
+	RefreshWindow time.Duration
+
+	dir    string
+	client mondo.Client
+
+	mu       sync.Mutex
+	accounts []mondo.Account
+	ledgers  map[string]*ledger
+}
+
+var _ mondo.Client = (*Cache)(nil)
+
+// Open returns a Cache stored in dir, creating the directory if need be. The client is used to sync, and may be nil to only read what is already stored.
+func Open(dir string, client mondo.Client) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	c := &Cache{
+		RefreshWindow: DefaultRefreshWindow,
+		dir:           dir,
+		client:        client,
+		ledgers:       map[string]*ledger{},
+	}
+
+	if err := readJSON(filepath.Join(dir, "accounts.json"), &c.accounts); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// ledger loads the stored transactions of an account. Must be called with c.mu held.
+func (c *Cache) ledger(accountId string) (*ledger, error) {
+	if l, ok := c.ledgers[accountId]; ok {
+		return l, nil
+	}
+
+	l, err := readLedger(c.ledgerPath(accountId))
+	if err != nil {
+		return nil, err
+	}
+
+	c.ledgers[accountId] = l
+	return l, nil
+}
+
+func (c *Cache) ledgerPath(accountId string) string {
+	return filepath.Join(c.dir, "transactions-"+filepath.Base(accountId)+".jsonl")
+}
+
+// LastSync returns when an account was last synced, or the zero time if it never has been.
+func (c *Cache) LastSync(accountId string) (time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	l, err := c.ledger(accountId)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return l.LastSync, nil
+}
+
+// Sync refreshes the list of accounts, then fetches an account's transactions that are not yet stored. Unsettled transactions created within RefreshWindow are fetched again, so that changes such as settlement are picked up.
+func (c *Cache) Sync(accountId string) (*SyncResult, error) {
+	if c.client == nil {
+		return nil, ErrNoClient
+	}
+
+	if accountId == "" {
+		return nil, fmt.Errorf("accountId cannot be empty")
+	}
+
+	accounts, err := c.client.Accounts()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := writeJSON(filepath.Join(c.dir, "accounts.json"), accounts); err != nil {
+		return nil, err
+	}
+	c.accounts = accounts
+
+	l, err := c.ledger(accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	since := c.syncFrom(l, now)
+
+	// Work on a copy, so that a failed sync leaves the stored transactions untouched.
+	txs := append([]mondo.Transaction(nil), l.Transactions...)
+	index := map[string]int{}
+	for i, tx := range txs {
+		index[tx.ID] = i
+	}
+
+	result := &SyncResult{}
+	var changed []mondo.Transaction
+	err = mondo.EachPage(c.client, accountId, since, "", 0, func(page []mondo.Transaction) error {
+		for _, tx := range page {
+			if i, ok := index[tx.ID]; ok {
+				if !sameTransaction(txs[i], tx) {
+					txs[i] = tx
+					changed = append(changed, tx)
+					result.Updated++
+				}
+				continue
+			}
+			index[tx.ID] = len(txs)
+			txs = append(txs, tx)
+			changed = append(changed, tx)
+			result.Added++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(txs, func(i, j int) bool {
+		return created(txs[i]).Before(created(txs[j]))
+	})
+
+	updated := &ledger{LastSync: now, Transactions: txs, lines: l.lines, size: l.size}
+	if err := updated.save(c.ledgerPath(accountId), changed); err != nil {
+		return nil, err
+	}
+	c.ledgers[accountId] = updated
+
+	return result, nil
+}
+
+// syncFrom returns the since parameter to sync from: the creation time of the oldest recent unsettled transaction if there is one, otherwise the ID of the newest stored transaction.
+func (c *Cache) syncFrom(l *ledger, now time.Time) string {
+	if len(l.Transactions) == 0 {
+		return ""
+	}
+
+	cutoff := now.Add(-c.RefreshWindow)
+	for _, tx := range l.Transactions {
+		if tx.Settled == "" && created(tx).After(cutoff) {
+			return tx.Created
+		}
+	}
+
+	return l.Transactions[len(l.Transactions)-1].ID
+}
+
+func sameTransaction(a, b mondo.Transaction) bool {
+	ab, _ := json.Marshal(a)
+	bb, _ := json.Marshal(b)
+	return string(ab) == string(bb)
+}
+
+// created returns when a transaction was created, or the zero time if that cannot be parsed.
+func created(tx mondo.Transaction) time.Time {
+	t, _ := time.Parse(time.RFC3339, tx.Created)
+	return t
+}
+
+// Accounts returns the accounts stored by the last Sync.
+func (c *Cache) Accounts() ([]mondo.Account, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]mondo.Account(nil), c.accounts...), nil
+}
+
+// Balance works out an account's balance from its newest stored transaction. It is only as fresh as the last Sync.
+func (c *Cache) Balance(accountId string) (*mondo.Balance, error) {
+	if accountId == "" {
+		return nil, fmt.Errorf("accountId cannot be empty")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	l, err := c.ledger(accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	balance := &mondo.Balance{Currency: "GBP"}
+	today := time.Now().Format("2006-01-02")
+	for _, tx := range l.Transactions {
+		balance.Balance = tx.AccountBalance
+		if tx.Currency != "" {
+			balance.Currency = tx.Currency
+		}
+		if created(tx).Local().Format("2006-01-02") == today && tx.Amount < 0 && !tx.IsLoad {
+			balance.SpendToday += tx.Amount
+		}
+	}
+
+	return balance, nil
+}
+
+// Transactions reads stored transactions with the same parameters as the API: since is a transaction ID to page after or an RFC3339 time, before is an RFC3339 time, and both may be empty. Unlike the API, a limit of zero or less returns every match.
+func (c *Cache) Transactions(accountId, since, before string, limit int) ([]mondo.Transaction, error) {
+	if accountId == "" {
+		return nil, fmt.Errorf("accountId cannot be empty")
+	}
+
+	var sinceTime, beforeTime time.Time
+	var sinceId string
+	if since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			sinceId = since
+		}
+		sinceTime = t
+	}
+	if before != "" {
+		t, err := time.Parse(time.RFC3339, before)
+		if err != nil {
+			return nil, fmt.Errorf("invalid before time %q: %v", before, err)
+		}
+		beforeTime = t
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	l, err := c.ledger(accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	txs := l.Transactions
+	if sinceId != "" {
+		for i, tx := range txs {
+			if tx.ID == sinceId {
+				txs = txs[i+1:]
+				break
+			}
+		}
+	}
+
+	page := []mondo.Transaction{}
+	for _, tx := range txs {
+		t := created(tx)
+		if t.Before(sinceTime) {
+			continue
+		}
+		if !beforeTime.IsZero() && !t.Before(beforeTime) {
+			break
+		}
+		if limit > 0 && len(page) == limit {
+			break
+		}
+		page = append(page, tx)
+	}
+
+	return page, nil
+}
+
+// TransactionByID reads a stored transaction.
+func (c *Cache) TransactionByID(accountId, transactionId string) (*mondo.Transaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	l, err := c.ledger(accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tx := range l.Transactions {
+		if tx.ID == transactionId {
+			return &tx, nil
+		}
+	}
+	return nil, mondo.ErrNoTransactionFound
+}
+
+func (c *Cache) AnnotateTransaction(transactionId string, metadata map[string]string) (*mondo.Transaction, error) {
+	return nil, ErrOffline
+}
+
+func (c *Cache) CreateFeedItem(accountId, title, imageURL, bgColor, bodyColor, titleColor, body string) error {
+	return ErrOffline
+}
+
+func (c *Cache) PostFeedItem(accountId string, item *mondo.FeedItem) error {
+	return ErrOffline
+}
+
+func (c *Cache) RegisterWebhook(accountId, URL string) (*mondo.Webhook, error) {
+	return nil, ErrOffline
+}
+
+func (c *Cache) ListWebhooks(accountId string) ([]mondo.Webhook, error) {
+	return nil, ErrOffline
+}
+
+func (c *Cache) DeleteWebhook(webhookId string) error {
+	return ErrOffline
+}
+
+func (c *Cache) RegisterAttachment(externalId, fileURL, fileType string) (*mondo.Attachment, error) {
+	return nil, ErrOffline
+}
+
+// readJSON decodes the file at path into v. A missing file leaves v untouched.
+func readJSON(path string, v interface{}) error {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("corrupt cache file %v: %v", path, err)
+	}
+	return nil
+}
+
+// writeJSON replaces the file at path atomically, so that a crash never leaves it half written.
+func writeJSON(path string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, b)
+}
+
+// writeFile replaces the file at path with b atomically.
+func writeFile(path string, b []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".cache")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// readLedger replays the log at path. A missing log is an empty ledger. A last line without a newline is what is left of an interrupted Sync, and is ignored.
+func readLedger(path string) (*ledger, error) {
+	l := &ledger{}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	index := map[string]int{}
+	for len(b) > 0 {
+		end := bytes.IndexByte(b, '\n')
+		if end < 0 {
+			break
+		}
+		line := b[:end]
+		b = b[end+1:]
+		l.size += int64(end + 1)
+		l.lines++
+
+		var e entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("corrupt cache file %v: line %v: %v", path, l.lines, err)
+		}
+		if e.Synced != nil {
+			l.LastSync = *e.Synced
+		}
+		if tx := e.Transaction; tx != nil {
+			if i, ok := index[tx.ID]; ok {
+				l.Transactions[i] = *tx
+				continue
+			}
+			index[tx.ID] = len(l.Transactions)
+			l.Transactions = append(l.Transactions, *tx)
+		}
+	}
+
+	sort.SliceStable(l.Transactions, func(i, j int) bool {
+		return created(l.Transactions[i]).Before(created(l.Transactions[j]))
+	})
+
+	return l, nil
+}
+
+// save appends the changed transactions and the time of the sync to the log at path. Once replaced entries outnumber the transactions kept, the log is rewritten with one entry per transaction instead.
+func (l *ledger) save(path string, changed []mondo.Transaction) error {
+	if l.lines+len(changed)+1-len(l.Transactions) > len(l.Transactions) {
+		l.lines, l.size = 0, 0
+		b, err := l.encode(l.Transactions)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(path, b); err != nil {
+			return err
+		}
+		l.size = int64(len(b))
+		return nil
+	}
+
+	b, err := l.encode(changed)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	// Drop anything left after the last whole entry by an interrupted write.
+	if err := f.Truncate(l.size); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.WriteAt(b, l.size); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	l.size += int64(len(b))
+	return nil
+}
+
+// encode returns log lines for txs followed by the time of the last sync, counting them in l.lines.
+func (l *ledger) encode(txs []mondo.Transaction) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := range txs {
+		if err := enc.Encode(entry{Transaction: &txs[i]}); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Encode(entry{Synced: &l.LastSync}); err != nil {
+		return nil, err
+	}
+
+	l.lines += len(txs) + 1
+	return buf.Bytes(), nil
+}
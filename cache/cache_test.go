@@ -0,0 +1,227 @@
+package cache
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/sjwhitworth/gomondo/mondotest"
+	"github.com/stretchr/testify/assert"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "cache")
+	assert.NoError(t, err)
+	return dir
+}
+
+func TestSync(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	srv := mondotest.NewServer()
+	defer srv.Close()
+
+	srv.AddUser("client", "secret", "user", "pass")
+	srv.AddAccount(mondo.Account{ID: "acc_1", Description: "Peter Pan's Account"})
+	srv.AddTransactions("acc_1",
+		mondo.Transaction{ID: "tx_1", Amount: -100, AccountBalance: 900, Created: "2015-08-22T12:00:00Z", Settled: "2015-08-23T12:00:00Z"},
+		mondo.Transaction{ID: "tx_2", Amount: -200, AccountBalance: 700, Created: "2015-08-23T12:00:00Z", Settled: "2015-08-24T12:00:00Z"},
+	)
+
+	client, err := mondo.Authenticate("client", "secret", "user", "pass")
+	assert.NoError(t, err)
+
+	c, err := Open(dir, client)
+	assert.NoError(t, err)
+
+	result, err := c.Sync("acc_1")
+	assert.NoError(t, err)
+	assert.Equal(t, &SyncResult{Added: 2}, result)
+
+	srv.AddTransactions("acc_1", mondo.Transaction{ID: "tx_3", Amount: -300, AccountBalance: 400, Created: "2015-08-24T12:00:00Z", Settled: "2015-08-25T12:00:00Z"})
+
+	result, err = c.Sync("acc_1")
+	assert.NoError(t, err)
+	assert.Equal(t, &SyncResult{Added: 1}, result)
+
+	// The second sync only asks for transactions after the newest one stored.
+	calls := srv.CallsTo("GET", "/transactions")
+	assert.Equal(t, "tx_2", calls[len(calls)-1].Params.Get("since"))
+
+	// A cache opened without a client reads what was stored.
+	offline, err := Open(dir, nil)
+	assert.NoError(t, err)
+
+	_, err = offline.Sync("acc_1")
+	assert.Equal(t, ErrNoClient, err)
+
+	accounts, err := offline.Accounts()
+	assert.NoError(t, err)
+	assert.Equal(t, "Peter Pan's Account", accounts[0].Description)
+
+	txs, err := offline.Transactions("acc_1", "", "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(txs))
+
+	txs, err = offline.Transactions("acc_1", "tx_1", "", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tx_2"}, ids(txs))
+
+	txs, err = offline.Transactions("acc_1", "2015-08-23T00:00:00Z", "2015-08-24T12:00:00Z", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tx_2"}, ids(txs))
+
+	tx, err := offline.TransactionByID("acc_1", "tx_3")
+	assert.NoError(t, err)
+	assert.Equal(t, -300, tx.Amount)
+
+	_, err = offline.TransactionByID("acc_1", "tx_missing")
+	assert.Equal(t, mondo.ErrNoTransactionFound, err)
+
+	balance, err := offline.Balance("acc_1")
+	assert.NoError(t, err)
+	assert.Equal(t, 400, balance.Balance)
+
+	last, err := offline.LastSync("acc_1")
+	assert.NoError(t, err)
+	assert.False(t, last.IsZero())
+
+	assert.Equal(t, ErrOffline, offline.CreateFeedItem("acc_1", "title", "", "", "", "", "body"))
+}
+
+func TestSyncRefreshesUnsettled(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	pendingCreated := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	pending := mondo.Transaction{ID: "tx_2", Amount: -200, Created: pendingCreated}
+	settled := pending
+	settled.Settled = time.Now().UTC().Format(time.RFC3339)
+
+	fake := &mondotest.FakeClient{}
+	fake.TransactionsReturnsOnCall(0, []mondo.Transaction{
+		{ID: "tx_1", Amount: -100, Created: "2015-08-22T12:00:00Z", Settled: "2015-08-23T12:00:00Z"},
+		pending,
+	}, nil)
+	fake.TransactionsReturnsOnCall(1, []mondo.Transaction{settled}, nil)
+
+	c, err := Open(dir, fake)
+	assert.NoError(t, err)
+
+	_, err = c.Sync("acc_1")
+	assert.NoError(t, err)
+
+	result, err := c.Sync("acc_1")
+	assert.NoError(t, err)
+	assert.Equal(t, &SyncResult{Updated: 1}, result)
+
+	_, since, _, _ := fake.TransactionsArgsForCall(1)
+	assert.Equal(t, pendingCreated, since)
+
+	tx, err := c.TransactionByID("acc_1", "tx_2")
+	assert.NoError(t, err)
+	assert.Equal(t, settled.Settled, tx.Settled)
+}
+
+func ids(txs []mondo.Transaction) []string {
+	var ids []string
+	for _, tx := range txs {
+		ids = append(ids, tx.ID)
+	}
+	return ids
+}
+
+func TestSyncAppends(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	fake := &mondotest.FakeClient{}
+	fake.TransactionsReturnsOnCall(0, []mondo.Transaction{
+		{ID: "tx_1", Amount: -100, Created: "2015-08-22T12:00:00Z", Settled: "2015-08-23T12:00:00Z"},
+		{ID: "tx_2", Amount: -200, Created: "2015-08-23T12:00:00Z", Settled: "2015-08-24T12:00:00Z"},
+	}, nil)
+	fake.TransactionsReturnsOnCall(1, []mondo.Transaction{
+		{ID: "tx_3", Amount: -300, Created: "2015-08-24T12:00:00Z", Settled: "2015-08-25T12:00:00Z"},
+	}, nil)
+
+	c, err := Open(dir, fake)
+	assert.NoError(t, err)
+
+	_, err = c.Sync("acc_1")
+	assert.NoError(t, err)
+
+	path := c.ledgerPath("acc_1")
+	before, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+
+	_, err = c.Sync("acc_1")
+	assert.NoError(t, err)
+
+	// The second sync leaves what was stored alone, and adds the new transaction and when it synced.
+	after, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, string(before), string(after[:len(before)]))
+	assert.Equal(t, 2, bytes.Count(after[len(before):], []byte("\n")))
+
+	// What is left of an interrupted write is ignored, and dropped by the next sync.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	assert.NoError(t, err)
+	_, err = f.WriteString(`{"transaction":{"id":"tx_4"`)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	reopened, err := Open(dir, fake)
+	assert.NoError(t, err)
+
+	txs, err := reopened.Transactions("acc_1", "", "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tx_1", "tx_2", "tx_3"}, ids(txs))
+
+	_, err = reopened.Sync("acc_1")
+	assert.NoError(t, err)
+
+	again, err := Open(dir, nil)
+	assert.NoError(t, err)
+
+	txs, err = again.Transactions("acc_1", "", "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tx_1", "tx_2", "tx_3"}, ids(txs))
+}
+
+func TestSyncCompacts(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	fake := &mondotest.FakeClient{}
+	fake.TransactionsReturns([]mondo.Transaction{
+		{ID: "tx_1", Amount: -100, Created: "2015-08-22T12:00:00Z", Settled: "2015-08-23T12:00:00Z"},
+	}, nil)
+
+	c, err := Open(dir, fake)
+	assert.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		_, err = c.Sync("acc_1")
+		assert.NoError(t, err)
+	}
+
+	// Entries recording each sync are compacted away rather than piling up.
+	b, err := ioutil.ReadFile(c.ledgerPath("acc_1"))
+	assert.NoError(t, err)
+	assert.True(t, bytes.Count(b, []byte("\n")) <= 3)
+
+	reopened, err := Open(dir, nil)
+	assert.NoError(t, err)
+
+	txs, err := reopened.Transactions("acc_1", "", "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tx_1"}, ids(txs))
+
+	last, err := reopened.LastSync("acc_1")
+	assert.NoError(t, err)
+	assert.False(t, last.IsZero())
+}
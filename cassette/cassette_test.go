@@ -0,0 +1,162 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/sjwhitworth/gomondo/mondotest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	defer func(c *http.Client) { mondo.HTTPClient = c }(mondo.HTTPClient)
+	defer func(u string) { mondo.BaseMondoURL = u }(mondo.BaseMondoURL)
+
+	srv := mondotest.NewServer()
+	srv.AddAccount(mondo.Account{ID: "acc_1", AccountNumber: "12345678", SortCode: "040004"})
+	srv.AddTransactions("acc_1", mondo.Transaction{ID: "tx_1", Amount: -510, Created: "2015-08-22T12:20:18Z"})
+
+	// Record a session against the fake.
+	var buf bytes.Buffer
+	mondo.HTTPClient = &http.Client{Transport: NewRecorder(&buf, nil)}
+
+	client, err := mondo.Authenticate("client", "s3cr3t", "user", "hunter2")
+	assert.NoError(t, err)
+	_, err = client.Accounts()
+	assert.NoError(t, err)
+	_, err = client.Transactions("acc_1", "", "", 100)
+	assert.NoError(t, err)
+	baseURL := mondo.BaseMondoURL
+	srv.Close()
+
+	cassette := buf.String()
+	assert.Equal(t, 3, strings.Count(cassette, "\n"))
+	for _, secret := range []string{"hunter2", "s3cr3t", "12345678", "040004", "token_0"} {
+		assert.NotContains(t, cassette, secret)
+	}
+
+	// Replay it with the fake gone.
+	rp, err := NewReplayer(strings.NewReader(cassette))
+	assert.NoError(t, err)
+	mondo.HTTPClient = &http.Client{Transport: rp}
+	mondo.BaseMondoURL = baseURL
+
+	client, err = mondo.Authenticate("client", "other secret", "user", "other password")
+	assert.NoError(t, err)
+	accounts, err := client.Accounts()
+	assert.NoError(t, err)
+	assert.Equal(t, "acc_redacted_d20de105", accounts[0].ID)
+	assert.Equal(t, Redacted, accounts[0].AccountNumber)
+
+	_, err = client.Transactions("acc_1", "", "", 50)
+	assert.Error(t, err)
+	assert.Error(t, rp.Unplayed())
+
+	transactions, err := client.Transactions("acc_1", "", "", 100)
+	assert.NoError(t, err)
+	assert.Equal(t, -510, transactions[0].Amount)
+	assert.NoError(t, rp.Unplayed())
+
+	// Interactions are only replayed once.
+	_, err = client.Accounts()
+	assert.Error(t, err)
+}
+
+func TestRecordScrubsPersonalDetails(t *testing.T) {
+	defer func(c *http.Client) { mondo.HTTPClient = c }(mondo.HTTPClient)
+	defer func(u string) { mondo.BaseMondoURL = u }(mondo.BaseMondoURL)
+
+	srv := mondotest.NewServer()
+	defer srv.Close()
+	srv.AddAccount(mondo.Account{ID: "acc_00009237aqC8c5umZmrRdh", Description: "Peter Pan's Account", Extra: map[string]json.RawMessage{"name": json.RawMessage(`"Peter Pan"`)}})
+	srv.AddAccount(mondo.Account{ID: "acc_00009237aqC8c5umZmrRdi", Description: "Wendy Darling's Account"})
+	srv.AddTransactions("acc_00009237aqC8c5umZmrRdh", mondo.Transaction{
+		ID:          "tx_1",
+		AccountID:   "acc_00009237aqC8c5umZmrRdh",
+		Amount:      -510,
+		Created:     "2015-08-22T12:20:18Z",
+		Description: "THE DE BEAUVOIR DELI C LONDON        GBR",
+		Notes:       "Salmon sandwich for Wendy",
+		Metadata:    map[string]interface{}{"notes": "Salmon sandwich for Wendy"},
+		Merchant: mondo.Merchant{
+			ID:   "merch_1",
+			Name: "The De Beauvoir Deli Co.",
+			Address: mondo.MerchantAddress{
+				Address:   "98 Southgate Road",
+				City:      "London",
+				Postcode:  "N1 3JD",
+				Latitude:  51.54151,
+				Longitude: -0.08482400000002599,
+			},
+		},
+	})
+
+	var buf bytes.Buffer
+	mondo.HTTPClient = &http.Client{Transport: NewRecorder(&buf, nil)}
+
+	client, err := mondo.Authenticate("client", "s3cr3t", "user", "hunter2")
+	assert.NoError(t, err)
+	_, err = client.Accounts()
+	assert.NoError(t, err)
+	_, err = client.Transactions("acc_00009237aqC8c5umZmrRdh", "", "", 100)
+	assert.NoError(t, err)
+	baseURL := mondo.BaseMondoURL
+
+	cassette := buf.String()
+	for _, personal := range []string{"acc_00009237aqC8c5umZmrRd", "Peter Pan", "Darling", "DE BEAUVOIR DELI C", "Wendy", "Southgate", "N1 3JD", "51.54", "0.0848"} {
+		assert.NotContains(t, cassette, personal)
+	}
+
+	// What is left still replays, with the scrubbed fields emptied.
+	rp, err := NewReplayer(strings.NewReader(cassette))
+	assert.NoError(t, err)
+	mondo.HTTPClient = &http.Client{Transport: rp}
+	mondo.BaseMondoURL = baseURL
+
+	client, err = mondo.Authenticate("client", "s3cr3t", "user", "hunter2")
+	assert.NoError(t, err)
+	accounts, err := client.Accounts()
+	assert.NoError(t, err)
+	if assert.Len(t, accounts, 2) {
+		// Accounts are still told apart, and their pseudonyms can be used to make further requests.
+		assert.NotEqual(t, accounts[0].ID, accounts[1].ID)
+		assert.Equal(t, Redacted, accounts[0].Description)
+		assert.Equal(t, json.RawMessage(`"REDACTED"`), accounts[0].Extra["name"])
+	}
+	transactions, err := client.Transactions(accounts[0].ID, "", "", 100)
+	assert.NoError(t, err)
+	if assert.Len(t, transactions, 1) {
+		assert.Equal(t, -510, transactions[0].Amount)
+		assert.Equal(t, "The De Beauvoir Deli Co.", transactions[0].Merchant.Name)
+		assert.Equal(t, Redacted, transactions[0].Merchant.Address.City)
+		assert.Equal(t, 0.0, transactions[0].Merchant.Address.Latitude)
+		assert.Equal(t, accounts[0].ID, transactions[0].AccountID)
+	}
+}
+
+func TestScrubberIDs(t *testing.T) {
+	s := NewScrubber(DefaultScrubKeys...)
+
+	id := s.id("acc_00009237aqC8c5umZmrRdh")
+	assert.Regexp(t, `^acc_redacted_[0-9a-f]{8}$`, id)
+	assert.Equal(t, id, s.id(id))
+	assert.NotEqual(t, id, s.id("acc_00009237aqC8c5umZmrRdi"))
+	assert.Equal(t, "acc_", s.id("acc_"))
+	assert.Equal(t, "tx_00008zIcpb1TB4yeIFXMzx", s.id("tx_00008zIcpb1TB4yeIFXMzx"))
+	assert.Equal(t, "hacc_1", s.id("hacc_1"))
+
+	assert.Equal(t, "/accounts/"+id+"/balance", s.Path("/accounts/acc_00009237aqC8c5umZmrRdh/balance"))
+
+	values := s.Values(url.Values{"account_id": {"acc_00009237aqC8c5umZmrRdh"}, "url": {"https://example.com/?account=acc_1"}})
+	assert.Equal(t, []string{id}, values["account_id"])
+	assert.Equal(t, []string{"https://example.com/?account=acc_redacted_d20de105"}, values["url"])
+
+	// Only account objects have their names scrubbed.
+	body := s.JSON([]byte(`{"account": {"id": "acc_1", "name": "Peter Pan"}, "merchant": {"id": "merch_1", "name": "Deli"}}`))
+	assert.JSONEq(t, `{"account": {"id": "acc_redacted_d20de105", "name": "REDACTED"}, "merchant": {"id": "merch_1", "name": "Deli"}}`, string(body))
+}
@@ -0,0 +1,76 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// Recorder is an http.RoundTripper that passes requests on to another transport, and writes each interaction to a cassette.
+type Recorder struct {
+	// Scrubber redacts interactions before they are written. Defaults to scrubbing DefaultScrubKeys.
+	Scrubber *Scrubber
+
+	next http.RoundTripper
+
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewRecorder returns a Recorder that writes interactions to w as JSON lines. If next is nil, http.DefaultTransport is used.
+func NewRecorder(w io.Writer, next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &Recorder{
+		Scrubber: NewScrubber(DefaultScrubKeys...),
+		next:     next,
+		enc:      json.NewEncoder(w),
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	in, err := newRequest(req, r.Scrubber)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(b))
+
+	header := http.Header{}
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		header.Set("Content-Type", ct)
+	}
+
+	interaction := Interaction{
+		Request: in,
+		Response: Response{
+			Status: resp.StatusCode,
+			Header: header,
+			Body:   r.Scrubber.JSON(b),
+		},
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.enc.Encode(&interaction); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,128 @@
+package cassette
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Replayer is an http.RoundTripper that answers requests from a cassette without touching the network. Requests are matched by method, path and parameters. Identical requests are answered in the order they were recorded. A request with no matching interaction fails with an error.
+type Replayer struct {
+	// Scrubber is applied to incoming requests before matching, and must match the one used when recording. Defaults to scrubbing DefaultScrubKeys.
+	Scrubber *Scrubber
+
+	mu      sync.Mutex
+	pending map[string][]*Interaction
+}
+
+// Load reads a cassette from a file.
+func Load(path string) (*Replayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return NewReplayer(f)
+}
+
+// NewReplayer reads a cassette of JSON-lines interactions from r.
+func NewReplayer(r io.Reader) (*Replayer, error) {
+	rp := &Replayer{
+		Scrubber: NewScrubber(DefaultScrubKeys...),
+		pending:  map[string][]*Interaction{},
+	}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+
+		var in Interaction
+		if err := json.Unmarshal(sc.Bytes(), &in); err != nil {
+			return nil, fmt.Errorf("cassette: line %v: %v", line, err)
+		}
+
+		k := in.Request.key()
+		rp.pending[k] = append(rp.pending[k], &in)
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return rp, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rp *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	in, err := newRequest(req, rp.Scrubber)
+	if err != nil {
+		return nil, err
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	k := in.key()
+	queue := rp.pending[k]
+	if len(queue) == 0 {
+		return nil, fmt.Errorf("cassette: no recorded interaction for %v", k)
+	}
+	interaction := queue[0]
+	rp.pending[k] = queue[1:]
+
+	body := []byte(interaction.Response.Body)
+	var s string
+	if err := json.Unmarshal(body, &s); err == nil {
+		// Bodies that were not JSON are stored as strings.
+		body = []byte(s)
+	}
+
+	header := http.Header{}
+	for k, v := range interaction.Response.Header {
+		header[k] = v
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+		StatusCode:    interaction.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// Unplayed returns an error describing any recorded interactions that have not been replayed, or nil if every interaction was used.
+func (rp *Replayer) Unplayed() error {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	var missed []string
+	for _, k := range sortedKeys(rp.pending) {
+		for range rp.pending[k] {
+			missed = append(missed, k)
+		}
+	}
+
+	if len(missed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("cassette: %v interactions were not replayed: %v", len(missed), strings.Join(missed, ", "))
+}
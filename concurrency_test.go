@@ -0,0 +1,163 @@
+package mondo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tokenServer issues numbered tokens, only accepts the latest, and lets each refresh token be used once, like the real API.
+type tokenServer struct {
+	mu        sync.Mutex
+	issued    int
+	refreshes int
+	current   string
+	refresh   string
+}
+
+func (s *tokenServer) issue(w http.ResponseWriter) {
+	s.issued++
+	s.current = fmt.Sprintf("access_%v", s.issued)
+	s.refresh = fmt.Sprintf("refresh_%v", s.issued)
+	fmt.Fprintf(w, `{"access_token": %q, "expires_in": 21600, "refresh_token": %q, "token_type": "Bearer"}`, s.current, s.refresh)
+}
+
+// expire invalidates the current access token, so that the next call has to refresh it.
+func (s *tokenServer) expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = ""
+}
+
+// serve starts serving the token endpoint and accounts, and points BaseMondoURL at them until close is called.
+func (s *tokenServer) serve() (close func()) {
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	prev := BaseMondoURL
+	BaseMondoURL = srv.URL
+
+	mux.HandleFunc("/oauth2/token",
+		func(w http.ResponseWriter, r *http.Request) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			switch r.FormValue("grant_type") {
+			case GrantTypePassword:
+				s.issue(w)
+			case GrantTypeRefreshToken:
+				if r.FormValue("refresh_token") != s.refresh {
+					w.WriteHeader(401)
+					return
+				}
+				s.refreshes++
+				s.issue(w)
+			}
+		},
+	)
+
+	mux.HandleFunc("/accounts",
+		func(w http.ResponseWriter, r *http.Request) {
+			s.mu.Lock()
+			valid := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ") == s.current
+			s.mu.Unlock()
+
+			if !valid {
+				w.WriteHeader(401)
+				return
+			}
+			fmt.Fprint(w, `{"accounts": [{"id": "acc_1"}]}`)
+		},
+	)
+
+	return func() {
+		srv.Close()
+		BaseMondoURL = prev
+	}
+}
+
+func TestConcurrentCalls(t *testing.T) {
+	s := &tokenServer{}
+	defer s.serve()()
+
+	client, err := Authenticate("some", "valid", "credentials", "here")
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			c := client
+			if i%2 == 0 {
+				c = client.WithContext(context.Background())
+			}
+
+			acs, err := c.Accounts()
+			assert.NoError(t, err)
+			assert.Len(t, acs, 1)
+			assert.True(t, c.Authenticated())
+			assert.False(t, c.ExpiresAt().IsZero())
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestConcurrentRefresh(t *testing.T) {
+	s := &tokenServer{}
+	defer s.serve()()
+
+	client, err := Authenticate("some", "valid", "credentials", "here")
+	assert.NoError(t, err)
+
+	for round := 1; round <= 3; round++ {
+		s.expire()
+
+		// Every call is rejected at first, but the token is only refreshed once.
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := client.Accounts()
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, round, s.refreshes)
+		assert.True(t, client.Authenticated())
+	}
+}
+
+func TestRefreshFails(t *testing.T) {
+	s := &tokenServer{}
+	defer s.serve()()
+
+	client, err := Authenticate("some", "valid", "credentials", "here")
+	assert.NoError(t, err)
+
+	s.mu.Lock()
+	s.current, s.refresh = "", ""
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.Accounts()
+			assert.Equal(t, ErrUnauthenticatedRequest, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 0, s.refreshes)
+	assert.False(t, client.auth.authenticated)
+}
@@ -0,0 +1,234 @@
+// Package digest renders summaries of an account's spending into feed items, and posts them on a schedule.
+//
+// A digest covers one period, such as yesterday or last week, and is rendered with text/template. Templates are executed with a Data, and can use these functions as well as the standard ones:
+//
+//	money    formats an amount in minor units, such as 1250, in the digest's currency: £12.50
+//	percent  formats a percentage without its sign, such as 12%
+//	plural   picks the singular or plural of a word for a count: {{plural .SpendCount "payment" "payments"}}
+package digest
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/sjwhitworth/gomondo/internal/mondoutil"
+	"github.com/sjwhitworth/gomondo/report"
+)
+
+// Period is the span of time a digest covers.
+type Period string
+
+const (
+	Daily  Period = "daily"
+	Weekly Period = "weekly"
+)
+
+// ParsePeriod parses the name of a Period, such as "weekly".
+func ParsePeriod(s string) (Period, error) {
+	switch p := Period(s); p {
+	case Daily, Weekly:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown period %q: use daily or weekly", s)
+}
+
+// shift moves t forward by n periods, or back if n is negative.
+func (p Period) shift(t time.Time, n int) time.Time {
+	if p == Weekly {
+		return t.AddDate(0, 0, 7*n)
+	}
+	return t.AddDate(0, 0, n)
+}
+
+// Purchase is a single spending transaction picked out by a digest.
+type Purchase struct {
+	Name        string
+	Amount      int
+	Created     time.Time
+	Transaction mondo.Transaction
+}
+
+// Data is what digest templates are executed with. Amounts are positive, in minor units such as pence.
+type Data struct {
+	Period Period
+
+	// Start and End bound the period covered, which includes Start but not End.
+	Start, End time.Time
+
+	Currency string
+
+	// Spend is the money spent in the period, across SpendCount transactions. Count includes every transaction, such as top ups and refunds.
+	Spend      int
+	SpendCount int
+	Count      int
+	Income     int
+	TopUps     int
+
+	// PreviousSpend is the money spent in the period before, and Change the percentage Spend is up on it, or down if negative. HasPrevious is false, and Change 0, if nothing was spent in the period before.
+	PreviousSpend int
+	HasPrevious   bool
+	Change        float64
+
+	// TopMerchants are the merchants most was spent at, largest first, with at most TopMerchantCount of them.
+	TopMerchants []report.Row
+
+	// Largest is the largest spending transaction, or nil if there was none.
+	Largest *Purchase
+
+	// Summary totals the period's transactions by category.
+	Summary *report.Summary
+}
+
+// The most merchants listed in Data.TopMerchants.
+var TopMerchantCount = 3
+
+// Build works out the Data for a period starting at start, from the transactions in it and in the period before it. Transactions outside both are ignored, so the two may be passed together.
+func Build(period Period, start time.Time, transactions []mondo.Transaction) (*Data, error) {
+	end := period.shift(start, 1)
+	previousStart := period.shift(start, -1)
+
+	var current []mondo.Transaction
+	d := &Data{Period: period, Start: start, End: end, Currency: "GBP"}
+	for _, tx := range transactions {
+		created, err := time.Parse(time.RFC3339, tx.Created)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %v has invalid created time: %v", tx.ID, err)
+		}
+
+		spend := tx.Amount < 0 && !tx.IsLoad
+		switch {
+		case !created.Before(start) && created.Before(end):
+			current = append(current, tx)
+			if tx.Currency != "" {
+				d.Currency = tx.Currency
+			}
+			if spend && (d.Largest == nil || -tx.Amount > d.Largest.Amount) {
+				d.Largest = &Purchase{Name: name(tx), Amount: -tx.Amount, Created: created, Transaction: tx}
+			}
+		case !created.Before(previousStart) && created.Before(start):
+			if spend {
+				d.PreviousSpend -= tx.Amount
+			}
+		}
+	}
+
+	byCategory, err := report.Summarise(current, report.ByCategory)
+	if err != nil {
+		return nil, err
+	}
+	d.Summary = byCategory
+	d.Count = byCategory.Total.Count
+	d.Spend = byCategory.Total.Spend
+	d.SpendCount = byCategory.Total.SpendCount
+	d.Income = byCategory.Total.Income
+	d.TopUps = byCategory.Total.TopUps
+
+	byMerchant, err := report.Summarise(current, report.ByMerchant)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range byMerchant.Rows {
+		if row.Spend == 0 || len(d.TopMerchants) == TopMerchantCount {
+			break
+		}
+		d.TopMerchants = append(d.TopMerchants, row)
+	}
+
+	if d.PreviousSpend > 0 {
+		d.HasPrevious = true
+		d.Change = 100 * float64(d.Spend-d.PreviousSpend) / float64(d.PreviousSpend)
+	}
+
+	return d, nil
+}
+
+func name(tx mondo.Transaction) string {
+	if tx.Merchant.Name != "" {
+		return tx.Merchant.Name
+	}
+	return tx.Description
+}
+
+// Template renders a Data into the title and body of a feed item.
+type Template struct {
+	Title string
+	Body  string
+}
+
+// DefaultTemplates are used for digests that do not set their own.
+var DefaultTemplates = map[Period]Template{
+	Daily: {
+		Title: `You spent {{money .Spend}} yesterday`,
+		Body: `{{if .SpendCount}}{{.SpendCount}} {{plural .SpendCount "payment" "payments"}}` +
+			`{{with .TopMerchants}}, mostly at {{range $i, $m := .}}{{if $i}}, {{end}}{{$m.Label}} ({{money $m.Spend}}){{end}}{{end}}.` +
+			`{{with .Largest}} The largest was {{money .Amount}} at {{.Name}}.{{end}}` +
+			`{{if .HasPrevious}} That's {{if ge .Change 0.0}}up{{else}}down{{end}} {{percent .Change}} on the day before.{{end}}` +
+			`{{else}}Nothing spent at all!{{end}}`,
+	},
+	Weekly: {
+		Title: `You spent {{money .Spend}} last week`,
+		Body: `{{if .SpendCount}}{{.SpendCount}} {{plural .SpendCount "payment" "payments"}} from {{.Start.Format "Mon 2 Jan"}}.` +
+			`{{with .TopMerchants}} Top {{plural (len .) "merchant" "merchants"}}: {{range $i, $m := .}}{{if $i}}, {{end}}{{$m.Label}} ({{money $m.Spend}}){{end}}.{{end}}` +
+			`{{with .Largest}} The largest was {{money .Amount}} at {{.Name}} on {{.Created.Format "Monday"}}.{{end}}` +
+			`{{if .HasPrevious}} That's {{if ge .Change 0.0}}up{{else}}down{{end}} {{percent .Change}} on the week before.{{end}}` +
+			`{{else}}Nothing spent all week!{{end}}`,
+	},
+}
+
+// Check parses the template, so that mistakes are found before it is first rendered.
+func (t Template) Check() error {
+	_, _, err := t.parse("GBP")
+	return err
+}
+
+func (t Template) parse(currency string) (*template.Template, *template.Template, error) {
+	funcs := template.FuncMap{
+		"money": func(amount int) string {
+			return mondoutil.FormatAmount(amount, currency)
+		},
+		"percent": func(p float64) string {
+			return fmt.Sprintf("%.0f%%", math.Abs(p))
+		},
+		"plural": func(n int, singular, plural string) string {
+			if n == 1 {
+				return singular
+			}
+			return plural
+		},
+	}
+
+	title, err := template.New("title").Funcs(funcs).Parse(t.Title)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid title template: %v", err)
+	}
+
+	body, err := template.New("body").Funcs(funcs).Parse(t.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid body template: %v", err)
+	}
+
+	return title, body, nil
+}
+
+// Render executes the template with d, returning a feed item with the results as its title and body.
+func (t Template) Render(d *Data) (*mondo.FeedItem, error) {
+	title, body, err := t.parse(d.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	var titleBuf, bodyBuf bytes.Buffer
+	if err := title.Execute(&titleBuf, d); err != nil {
+		return nil, err
+	}
+	if err := body.Execute(&bodyBuf, d); err != nil {
+		return nil, err
+	}
+
+	return mondo.NewFeedItem(strings.TrimSpace(titleBuf.String())).WithBody(strings.TrimSpace(bodyBuf.String())), nil
+}
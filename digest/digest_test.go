@@ -0,0 +1,197 @@
+package digest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/sjwhitworth/gomondo/mondotest"
+	"github.com/stretchr/testify/assert"
+)
+
+var transactions = []mondo.Transaction{
+	// The day before.
+	{ID: "tx_0", Amount: -1000, Currency: "GBP", Created: "2015-08-21T12:00:00Z", Merchant: mondo.Merchant{GroupID: "grp_tesco", Name: "Tesco"}},
+	// The day covered.
+	{ID: "tx_1", Amount: -350, Currency: "GBP", Created: "2015-08-22T08:00:00Z", Category: "eating_out", Merchant: mondo.Merchant{GroupID: "grp_pret", Name: "Pret A Manger"}},
+	{ID: "tx_2", Amount: -400, Currency: "GBP", Created: "2015-08-22T12:00:00Z", Category: "eating_out", Merchant: mondo.Merchant{GroupID: "grp_pret", Name: "Pret A Manger"}},
+	{ID: "tx_3", Amount: -500, Currency: "GBP", Created: "2015-08-22T18:00:00Z", Category: "groceries", Merchant: mondo.Merchant{GroupID: "grp_tesco", Name: "Tesco"}},
+	{ID: "tx_4", Amount: 2000, Currency: "GBP", Created: "2015-08-22T19:00:00Z", IsLoad: true},
+	// After.
+	{ID: "tx_5", Amount: -9999, Currency: "GBP", Created: "2015-08-23T00:00:00Z"},
+}
+
+func TestBuild(t *testing.T) {
+	d, err := Build(Daily, time.Date(2015, 8, 22, 0, 0, 0, 0, time.UTC), transactions)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1250, d.Spend)
+	assert.Equal(t, 3, d.SpendCount)
+	assert.Equal(t, 4, d.Count)
+	assert.Equal(t, 2000, d.TopUps)
+	assert.Equal(t, 1000, d.PreviousSpend)
+	assert.True(t, d.HasPrevious)
+	assert.Equal(t, 25.0, d.Change)
+	assert.Equal(t, 2, len(d.TopMerchants))
+	assert.Equal(t, "Pret A Manger", d.TopMerchants[0].Label)
+	assert.Equal(t, 750, d.TopMerchants[0].Spend)
+	assert.Equal(t, "Tesco", d.Largest.Name)
+	assert.Equal(t, 500, d.Largest.Amount)
+	assert.Equal(t, "eating_out", d.Summary.Rows[0].Key)
+}
+
+func TestRender(t *testing.T) {
+	d, err := Build(Daily, time.Date(2015, 8, 22, 0, 0, 0, 0, time.UTC), transactions)
+	assert.NoError(t, err)
+
+	item, err := DefaultTemplates[Daily].Render(d)
+	assert.NoError(t, err)
+	assert.Equal(t, "You spent £12.50 yesterday", item.Title)
+	assert.Equal(t, "3 payments, mostly at Pret A Manger (£7.50), Tesco (£5.00). The largest was £5.00 at Tesco. That's up 25% on the day before.", item.Body)
+
+	empty, err := Build(Weekly, time.Date(2015, 9, 7, 0, 0, 0, 0, time.UTC), transactions)
+	assert.NoError(t, err)
+	item, err = DefaultTemplates[Weekly].Render(empty)
+	assert.NoError(t, err)
+	assert.Equal(t, "Nothing spent all week!", item.Body)
+
+	item, err = Template{Title: "{{.SpendCount}} {{plural .SpendCount \"thing\" \"things\"}}", Body: "{{percent -12.4}}"}.Render(d)
+	assert.NoError(t, err)
+	assert.Equal(t, "3 things", item.Title)
+	assert.Equal(t, "12%", item.Body)
+
+	assert.Error(t, Template{Title: "{{.Spend"}.Check())
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig(strings.NewReader(`{"digests": [{"name": "daily", "period": "daily", "at": "08:30"}, {"name": "weekly", "period": "weekly", "weekday": "Sunday"}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 8*time.Hour+30*time.Minute, cfg.Digests[0].at)
+	assert.Equal(t, time.Sunday, cfg.Digests[1].weekday)
+	assert.Equal(t, DefaultImageURL, cfg.ImageURL)
+
+	for _, bad := range []string{
+		`{"digests": [{"name": "a", "period": "hourly"}]}`,
+		`{"digests": [{"name": "a", "period": "daily", "at": "25:00"}]}`,
+		`{"digests": [{"name": "a", "period": "weekly", "weekday": "someday"}]}`,
+		`{"digests": [{"name": "a", "period": "daily", "title": "{{"}]}`,
+		`{"digests": [{"name": "a", "period": "daily"}, {"name": "a", "period": "weekly"}]}`,
+	} {
+		_, err := ParseConfig(strings.NewReader(bad))
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestDue(t *testing.T) {
+	cfg, err := ParseConfig(strings.NewReader(`{"digests": [{"name": "daily", "period": "daily", "at": "08:00"}, {"name": "weekly", "period": "weekly", "at": "09:00"}]}`))
+	assert.NoError(t, err)
+	daily, weekly := cfg.Digests[0], cfg.Digests[1]
+
+	// Saturday 22 August 2015.
+	due, start := daily.due(time.Date(2015, 8, 22, 7, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2015, 8, 21, 8, 0, 0, 0, time.UTC), due)
+	assert.Equal(t, time.Date(2015, 8, 20, 0, 0, 0, 0, time.UTC), start)
+
+	due, start = daily.due(time.Date(2015, 8, 22, 9, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2015, 8, 22, 8, 0, 0, 0, time.UTC), due)
+	assert.Equal(t, time.Date(2015, 8, 21, 0, 0, 0, 0, time.UTC), start)
+
+	due, start = weekly.due(time.Date(2015, 8, 22, 9, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2015, 8, 17, 9, 0, 0, 0, time.UTC), due)
+	assert.Equal(t, time.Date(2015, 8, 10, 0, 0, 0, 0, time.UTC), start)
+
+	due, _ = weekly.due(time.Date(2015, 8, 24, 8, 59, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2015, 8, 17, 9, 0, 0, 0, time.UTC), due)
+}
+
+func TestSchedulerTick(t *testing.T) {
+	dir, err := ioutil.TempDir("", "digest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg, err := ParseConfig(strings.NewReader(`{"digests": [{"name": "daily", "period": "daily", "at": "08:00", "url": "https://example.com/"}]}`))
+	assert.NoError(t, err)
+
+	store, err := OpenFileStore(filepath.Join(dir, "state.json"))
+	assert.NoError(t, err)
+
+	fake := &mondotest.FakeClient{}
+	fake.TransactionsReturns(transactions, nil)
+
+	s := NewScheduler(fake, "acc_1", cfg, store)
+	s.Location = time.UTC
+
+	posted, err := s.Tick(time.Date(2015, 8, 23, 8, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"daily"}, posted)
+
+	accountId, since, before, _ := fake.TransactionsArgsForCall(0)
+	assert.Equal(t, "acc_1", accountId)
+	assert.Equal(t, "2015-08-21T00:00:00Z", since)
+	assert.Equal(t, "2015-08-23T00:00:00Z", before)
+
+	assert.Equal(t, 1, fake.PostFeedItemCallCount())
+	_, item := fake.PostFeedItemArgsForCall(0)
+	assert.Equal(t, "You spent £12.50 yesterday", item.Title)
+	assert.Equal(t, "https://example.com/", item.URL)
+	assert.Equal(t, DefaultImageURL, item.ImageURL)
+
+	// Later in the same period, and after a restart, nothing more is posted.
+	store, err = OpenFileStore(filepath.Join(dir, "state.json"))
+	assert.NoError(t, err)
+	s = NewScheduler(fake, "acc_1", cfg, store)
+	s.Location = time.UTC
+
+	posted, err = s.Tick(time.Date(2015, 8, 23, 23, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Empty(t, posted)
+	assert.Equal(t, 1, fake.PostFeedItemCallCount())
+
+	posted, err = s.Tick(time.Date(2015, 8, 24, 8, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"daily"}, posted)
+}
+
+func TestSchedulerTickErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "digest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// The first digest cannot be rendered, which must not stop the second being posted.
+	cfg, err := ParseConfig(strings.NewReader(`{"digests": [
+		{"name": "broken", "period": "daily", "title": "{{.NoSuchField}}"},
+		{"name": "daily", "period": "daily"}
+	]}`))
+	assert.NoError(t, err)
+
+	store, err := OpenFileStore(filepath.Join(dir, "state.json"))
+	assert.NoError(t, err)
+
+	fake := &mondotest.FakeClient{}
+	fake.TransactionsReturns(transactions, nil)
+
+	s := NewScheduler(fake, "acc_1", cfg, store)
+	s.Location = time.UTC
+
+	posted, err := s.Tick(time.Date(2015, 8, 23, 8, 0, 0, 0, time.UTC))
+	assert.Equal(t, []string{"daily"}, posted)
+	if assert.IsType(t, Errors{}, err) {
+		assert.Len(t, err.(Errors), 1)
+		assert.Contains(t, err.(Errors)["broken"].Error(), "failed to render")
+	}
+	assert.Equal(t, 1, fake.PostFeedItemCallCount())
+
+	// A failure to post is reported for each digest, and the others are still attempted.
+	fake.PostFeedItemReturns(fmt.Errorf("boom"))
+	posted, err = s.Tick(time.Date(2015, 8, 24, 8, 0, 0, 0, time.UTC))
+	assert.Empty(t, posted)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "2 digests failed: broken: failed to render")
+		assert.Contains(t, err.Error(), "; daily: failed to post: boom")
+	}
+}
@@ -0,0 +1,279 @@
+package digest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/sjwhitworth/gomondo/internal/mondoutil"
+)
+
+// Schedule is a digest posted once per period. Daily digests are posted each day at At, covering the day before. Weekly digests are posted each week on Weekday at At, covering the seven days before.
+type Schedule struct {
+	// Name identifies the digest, and must be unique.
+	Name   string `json:"name"`
+	Period Period `json:"period"`
+
+	// At is the time of day to post, such as 08:30. Defaults to midnight.
+	At string `json:"at,omitempty"`
+
+	// Weekday is the day weekly digests are posted, such as monday. Defaults to monday.
+	Weekday string `json:"weekday,omitempty"`
+
+	// Title and Body are templates for the feed item. Defaults to DefaultTemplates for the period.
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+
+	// URL is opened when the feed item is tapped.
+	URL string `json:"url,omitempty"`
+
+	at      time.Duration
+	weekday time.Weekday
+}
+
+// Template returns the templates the digest is rendered with.
+func (s Schedule) Template() Template {
+	t := DefaultTemplates[s.Period]
+	if s.Title != "" {
+		t.Title = s.Title
+	}
+	if s.Body != "" {
+		t.Body = s.Body
+	}
+	return t
+}
+
+// Config is a set of digests to post.
+type Config struct {
+	Digests []Schedule `json:"digests"`
+
+	// ImageURL is shown alongside digests in the feed.
+	ImageURL string `json:"image_url,omitempty"`
+}
+
+// The image shown alongside digests in the feed, unless the config sets its own.
+var DefaultImageURL = "https://blog.golang.org/gopher/gopher.png"
+
+// LoadConfig reads a Config from a JSON file.
+func LoadConfig(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseConfig(f)
+}
+
+// ParseConfig reads a Config from JSON, checks it and fills in defaults.
+func ParseConfig(r io.Reader) (*Config, error) {
+	cfg := &Config{}
+	if err := json.NewDecoder(r).Decode(cfg); err != nil {
+		return nil, fmt.Errorf("invalid digest config: %v", err)
+	}
+
+	weekdays := map[string]time.Weekday{}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		weekdays[strings.ToLower(d.String())] = d
+	}
+
+	seen := map[string]bool{}
+	for i := range cfg.Digests {
+		s := &cfg.Digests[i]
+		if s.Name == "" {
+			return nil, fmt.Errorf("digest %v has no name", i)
+		}
+		if seen[s.Name] {
+			return nil, fmt.Errorf("there is more than one digest named %v", s.Name)
+		}
+		seen[s.Name] = true
+
+		if _, err := ParsePeriod(string(s.Period)); err != nil {
+			return nil, fmt.Errorf("digest %v: %v", s.Name, err)
+		}
+
+		if s.At != "" {
+			t, err := time.Parse("15:04", s.At)
+			if err != nil {
+				return nil, fmt.Errorf("digest %v has invalid time %q: use HH:MM", s.Name, s.At)
+			}
+			s.at = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+		}
+
+		s.weekday = time.Monday
+		if s.Weekday != "" {
+			d, ok := weekdays[strings.ToLower(s.Weekday)]
+			if !ok {
+				return nil, fmt.Errorf("digest %v has invalid weekday %q", s.Name, s.Weekday)
+			}
+			s.weekday = d
+		}
+
+		if err := s.Template().Check(); err != nil {
+			return nil, fmt.Errorf("digest %v: %v", s.Name, err)
+		}
+	}
+
+	if cfg.ImageURL == "" {
+		cfg.ImageURL = DefaultImageURL
+	}
+
+	return cfg, nil
+}
+
+// due returns when the digest was last due at or before now, and the start of the period that post covers.
+func (s Schedule) due(now time.Time) (time.Time, time.Time) {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if s.Period == Weekly {
+		midnight = midnight.AddDate(0, 0, -int((7+now.Weekday()-s.weekday)%7))
+	}
+
+	due := midnight.Add(s.at)
+	if due.After(now) {
+		midnight = s.Period.shift(midnight, -1)
+		due = midnight.Add(s.at)
+	}
+
+	return due, s.Period.shift(midnight, -1)
+}
+
+// Store remembers which digests have been posted, so that each is posted at most once.
+type Store interface {
+	Posted(key string) (bool, error)
+	MarkPosted(key string) error
+}
+
+// FileStore is a Store persisted to a JSON file, so that digests are not repeated across restarts. It is safe for concurrent use.
+type FileStore struct {
+	keys *mondoutil.KeyStore
+}
+
+// OpenFileStore loads a FileStore from path. The file is created when the first digest is marked.
+func OpenFileStore(path string) (*FileStore, error) {
+	keys, err := mondoutil.OpenKeyStore(path)
+	if err != nil {
+		return nil, err
+	}
+	return &FileStore{keys: keys}, nil
+}
+
+func (s *FileStore) Posted(key string) (bool, error) {
+	return s.keys.Has(key)
+}
+
+// MarkPosted records key, and rewrites the file atomically.
+func (s *FileStore) MarkPosted(key string) error {
+	return s.keys.Add(key)
+}
+
+// Scheduler posts the digests in a Config to an account's feed when they fall due.
+type Scheduler struct {
+	// Location is the time zone that days and times are measured in. Defaults to time.Local.
+	Location *time.Location
+
+	client    mondo.Client
+	accountId string
+	cfg       *Config
+	store     Store
+}
+
+// NewScheduler returns a Scheduler for an account.
+func NewScheduler(client mondo.Client, accountId string, cfg *Config, store Store) *Scheduler {
+	return &Scheduler{
+		Location:  time.Local,
+		client:    client,
+		accountId: accountId,
+		cfg:       cfg,
+		store:     store,
+	}
+}
+
+// Render builds and renders a digest for the period that was most recently due at now, without posting it.
+func (s *Scheduler) Render(digest Schedule, now time.Time) (*mondo.FeedItem, error) {
+	_, start := digest.due(now.In(s.Location))
+
+	// Fetch the period before too, so the digest can compare against it.
+	var transactions []mondo.Transaction
+	since := digest.Period.shift(start, -1).Format(time.RFC3339)
+	before := digest.Period.shift(start, 1).Format(time.RFC3339)
+	err := mondo.EachPage(s.client, s.accountId, since, before, 0, func(page []mondo.Transaction) error {
+		transactions = append(transactions, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := Build(digest.Period, start, transactions)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := digest.Template().Render(data)
+	if err != nil {
+		return nil, err
+	}
+	return item.WithImage(s.cfg.ImageURL).WithURL(digest.URL), nil
+}
+
+// Tick posts every digest that has fallen due by now and not been posted for its period. A digest is marked as posted before it is sent, so it is never posted twice, even if sending fails or the process dies part way. A digest that fails does not stop the others being posted. It returns the names of the digests posted, and an Errors of those that failed, or nil if none did.
+func (s *Scheduler) Tick(now time.Time) ([]string, error) {
+	var posted []string
+	errs := Errors{}
+	for _, digest := range s.cfg.Digests {
+		_, start := digest.due(now.In(s.Location))
+
+		key := fmt.Sprintf("%v/%v/%v", s.accountId, digest.Name, start.Format("2006-01-02"))
+		done, err := s.store.Posted(key)
+		if err != nil {
+			errs[digest.Name] = err
+			continue
+		}
+		if done {
+			continue
+		}
+
+		item, err := s.Render(digest, now)
+		if err != nil {
+			errs[digest.Name] = fmt.Errorf("failed to render: %v", err)
+			continue
+		}
+
+		if err := s.store.MarkPosted(key); err != nil {
+			errs[digest.Name] = err
+			continue
+		}
+		if err := s.client.PostFeedItem(s.accountId, item); err != nil {
+			errs[digest.Name] = fmt.Errorf("failed to post: %v", err)
+			continue
+		}
+		posted = append(posted, digest.Name)
+	}
+
+	if len(errs) > 0 {
+		return posted, errs
+	}
+	return posted, nil
+}
+
+// Errors maps the names of digests to why they could not be posted.
+type Errors map[string]error
+
+func (e Errors) Error() string {
+	var parts []string
+	for name, err := range e {
+		parts = append(parts, fmt.Sprintf("%v: %v", name, err))
+	}
+	sort.Strings(parts)
+
+	digests := "digests"
+	if len(e) == 1 {
+		digests = "digest"
+	}
+	return fmt.Sprintf("%v %v failed: %v", len(e), digests, strings.Join(parts, "; "))
+}
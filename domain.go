@@ -1,6 +1,9 @@
 package mondo
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type tokenRequest struct {
 	GrantType    string `json:"grant_type"`
@@ -26,9 +29,19 @@ type Account struct {
 	SortCode      string    `json:"sort_code"`
 	Description   string    `json:"description"`
 	Created       time.Time `json:"created"`
+
+	// Extra holds fields from the API that this package does not know about.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+type Balance struct {
+	Balance    int    `json:"balance"`
+	Currency   string `json:"currency"`
+	SpendToday int    `json:"spend_today"`
 }
 
 type Transaction struct {
+	AccountID      string                 `json:"account_id"`
 	AccountBalance int                    `json:"account_balance"`
 	Amount         int                    `json:"amount"`
 	Attachments    []interface{}          `json:"attachments"`
@@ -42,6 +55,9 @@ type Transaction struct {
 	Metadata       map[string]interface{} `json:"metadata"`
 	Notes          string                 `json:"notes"`
 	Settled        string                 `json:"settled"`
+
+	// Extra holds fields from the API that this package does not know about.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 type Merchant struct {
@@ -54,6 +70,9 @@ type Merchant struct {
 	Logo     string          `json:"logo"`
 	Name     string          `json:"name"`
 	Online   bool            `json:"online"`
+
+	// Extra holds fields from the API that this package does not know about.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 type MerchantAddress struct {
@@ -74,6 +93,9 @@ type Webhook struct {
 	AccountId string `json:"account_id"`
 	Id        string `json:"id"`
 	Url       string `json:"url"`
+
+	// Extra holds fields from the API that this package does not know about.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 type Attachment struct {
@@ -83,6 +105,9 @@ type Attachment struct {
 	FileUrl    string `json:"file_url"`
 	FileType   string `json:"file_type"`
 	Created    string `json:"created"`
+
+	// Extra holds fields from the API that this package does not know about.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 type WebhookRequest struct {
@@ -0,0 +1,120 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/sjwhitworth/gomondo"
+)
+
+// AccountMap decides which ledger or beancount account the other side of each transaction is booked to. It is usually loaded from a JSON rules file:
+//
+//	{
+//	  "asset_account": "Assets:Mondo",
+//	  "default_expense": "Expenses:Uncategorised",
+//	  "default_income": "Income:Uncategorised",
+//	  "categories": {
+//	    "eating_out": "Expenses:Food:EatingOut",
+//	    "mondo": "Assets:Current"
+//	  },
+//	  "merchants": [
+//	    {"match": "(?i)tesco|sainsbury", "account": "Expenses:Food:Groceries"}
+//	  ]
+//	}
+//
+// Merchant rules are tried in order, matching against the merchant name, and take precedence over categories.
+type AccountMap struct {
+	// AssetAccount is the account representing the Mondo account itself.
+	AssetAccount string `json:"asset_account"`
+
+	// DefaultExpense and DefaultIncome are used for spending and income that no rule matches.
+	DefaultExpense string `json:"default_expense"`
+	DefaultIncome  string `json:"default_income"`
+
+	// Categories maps Mondo categories, such as eating_out, to accounts.
+	Categories map[string]string `json:"categories"`
+
+	// Merchants maps merchant names to accounts.
+	Merchants []MerchantRule `json:"merchants"`
+}
+
+// MerchantRule books transactions whose merchant name matches a regular expression to an account.
+type MerchantRule struct {
+	Match   string `json:"match"`
+	Account string `json:"account"`
+
+	re *regexp.Regexp
+}
+
+// DefaultAccountMap is used when no rules file is given.
+func DefaultAccountMap() *AccountMap {
+	m := &AccountMap{}
+	m.setDefaults()
+	return m
+}
+
+// LoadAccountMap reads an AccountMap from a JSON rules file.
+func LoadAccountMap(path string) (*AccountMap, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseAccountMap(f)
+}
+
+// ParseAccountMap reads an AccountMap from JSON.
+func ParseAccountMap(r io.Reader) (*AccountMap, error) {
+	m := &AccountMap{}
+	if err := json.NewDecoder(r).Decode(m); err != nil {
+		return nil, fmt.Errorf("invalid account rules: %v", err)
+	}
+
+	for i := range m.Merchants {
+		re, err := regexp.Compile(m.Merchants[i].Match)
+		if err != nil {
+			return nil, fmt.Errorf("invalid merchant rule %q: %v", m.Merchants[i].Match, err)
+		}
+		if m.Merchants[i].Account == "" {
+			return nil, fmt.Errorf("merchant rule %q has no account", m.Merchants[i].Match)
+		}
+		m.Merchants[i].re = re
+	}
+
+	m.setDefaults()
+	return m, nil
+}
+
+func (m *AccountMap) setDefaults() {
+	if m.AssetAccount == "" {
+		m.AssetAccount = "Assets:Mondo"
+	}
+	if m.DefaultExpense == "" {
+		m.DefaultExpense = "Expenses:Uncategorised"
+	}
+	if m.DefaultIncome == "" {
+		m.DefaultIncome = "Income:Uncategorised"
+	}
+}
+
+// Account returns the account the other side of t is booked to.
+func (m *AccountMap) Account(t mondo.Transaction) string {
+	for _, rule := range m.Merchants {
+		if rule.re != nil && rule.re.MatchString(payee(t)) {
+			return rule.Account
+		}
+	}
+
+	if a, ok := m.Categories[t.Category]; ok {
+		return a
+	}
+
+	if t.Amount > 0 {
+		return m.DefaultIncome
+	}
+	return m.DefaultExpense
+}
@@ -0,0 +1,84 @@
+// Package export converts Mondo transactions into file formats understood by accounting software.
+package export
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sjwhitworth/gomondo"
+)
+
+// Options controls which transactions are exported, and how.
+type Options struct {
+	// IncludePending exports transactions that have not yet settled. Their amounts may still change, so they are left out by default.
+	IncludePending bool
+
+	// SkipIDs holds the IDs of transactions that have already been exported, which are left out. See ExportedIDs.
+	SkipIDs map[string]bool
+
+	// QIFDateFormat is the layout, as understood by time.Format, used for dates in QIF files. Defaults to DefaultQIFDateFormat.
+	QIFDateFormat string
+}
+
+var (
+	// QIF has no standard date format. Mondo accounts are British, so we default to the day first.
+	DefaultQIFDateFormat = "02/01/2006"
+)
+
+// entry is a transaction prepared for export.
+type entry struct {
+	mondo.Transaction
+	created time.Time
+	settled time.Time
+}
+
+func (e entry) pending() bool {
+	return e.settled.IsZero()
+}
+
+// posted returns the date a transaction should be recorded against. For pending transactions this is the date it was created.
+func (e entry) posted() time.Time {
+	if e.pending() {
+		return e.created
+	}
+	return e.settled
+}
+
+// prepare parses the timestamps of transactions, drops those that opts excludes and sorts the rest by creation time.
+func prepare(transactions []mondo.Transaction, opts Options) ([]entry, error) {
+	var entries []entry
+	for _, t := range transactions {
+		e := entry{Transaction: t}
+
+		var err error
+		if e.created, err = time.Parse(time.RFC3339, t.Created); err != nil {
+			return nil, fmt.Errorf("transaction %v has invalid created time: %v", t.ID, err)
+		}
+
+		if t.Settled != "" {
+			if e.settled, err = time.Parse(time.RFC3339, t.Settled); err != nil {
+				return nil, fmt.Errorf("transaction %v has invalid settled time: %v", t.ID, err)
+			}
+		}
+
+		if (e.pending() && !opts.IncludePending) || opts.SkipIDs[t.ID] {
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].created.Before(entries[j].created) })
+	return entries, nil
+}
+
+// payee returns the name of the other party in a transaction.
+func payee(t mondo.Transaction) string {
+	if t.Category == "mondo" {
+		return "Mondo"
+	}
+	if t.Merchant.Name != "" {
+		return t.Merchant.Name
+	}
+	return t.Description
+}
@@ -0,0 +1,131 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	account = mondo.Account{
+		ID:            "acc_00009237aqC8c5umZmrRdh",
+		Description:   "Peter Pan's Account",
+		SortCode:      "04-00-04",
+		AccountNumber: "12345678",
+	}
+
+	transactions = []mondo.Transaction{
+		{
+			ID:             "tx_00008zL2INM3xZ41THuRF3",
+			Amount:         -679,
+			AccountBalance: 12334,
+			Currency:       "GBP",
+			Created:        "2015-08-23T16:15:03Z",
+			Category:       "entertainment",
+			Merchant:       mondo.Merchant{Name: "Vue Cinemas Islington Multiplex Screen"},
+		},
+		{
+			ID:             "tx_00008zIcpb1TB4yeIFXMzx",
+			Amount:         -510,
+			AccountBalance: 13013,
+			Currency:       "GBP",
+			Created:        "2015-08-22T12:20:18Z",
+			Settled:        "2015-08-23T12:20:18Z",
+			Category:       "eating_out",
+			Notes:          "Salmon sandwich 🍞",
+			Merchant:       mondo.Merchant{Name: "The De Beauvoir Deli Co."},
+		},
+		{
+			ID:             "tx_00008zjky19HyFLAzlUk7t",
+			Amount:         10000,
+			AccountBalance: 23013,
+			Currency:       "GBP",
+			Created:        "2015-08-21T09:00:00Z",
+			Settled:        "2015-08-21T09:00:00Z",
+			Category:       "mondo",
+			IsLoad:         true,
+		},
+	}
+)
+
+func TestWriteOFX(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, WriteOFX(&buf, account, transactions, Options{}))
+
+	out := buf.String()
+	assert.Contains(t, out, `<?OFX OFXHEADER="200" VERSION="211"`)
+	assert.Contains(t, out, "<BANKID>040004</BANKID>")
+	assert.Contains(t, out, "<ACCTID>12345678</ACCTID>")
+	assert.NotContains(t, out, "tx_00008zL2INM3xZ41THuRF3")
+
+	// The document after the processing instructions must be well formed.
+	var doc ofxDocument
+	body := out[bytes.Index(buf.Bytes(), []byte("<OFX>")):]
+	assert.NoError(t, xml.Unmarshal([]byte(body), &doc))
+
+	stmt := doc.Bank.Statement
+	assert.Equal(t, 2, len(stmt.Transactions))
+	assert.Equal(t, "CREDIT", stmt.Transactions[0].TrnType)
+	assert.Equal(t, "100.00", stmt.Transactions[0].TrnAmt)
+	assert.Equal(t, "Mondo", stmt.Transactions[0].Name)
+	assert.Equal(t, "DEBIT", stmt.Transactions[1].TrnType)
+	assert.Equal(t, "-5.10", stmt.Transactions[1].TrnAmt)
+	assert.Equal(t, "tx_00008zIcpb1TB4yeIFXMzx", stmt.Transactions[1].FITID)
+	assert.Equal(t, "20150823122018.000[0:UTC]", stmt.Transactions[1].DTPosted)
+	assert.Equal(t, "20150822122018.000[0:UTC]", stmt.Transactions[1].DTUser)
+	assert.Equal(t, "130.13", stmt.BalAmt)
+	assert.Equal(t, "20150821090000.000[0:UTC]", stmt.DTStart)
+	assert.Equal(t, "20150823122018.000[0:UTC]", stmt.DTEnd)
+
+	buf.Reset()
+	assert.NoError(t, WriteOFX(&buf, account, transactions, Options{IncludePending: true}))
+	doc = ofxDocument{}
+	assert.NoError(t, xml.Unmarshal(buf.Bytes()[bytes.Index(buf.Bytes(), []byte("<OFX>")):], &doc))
+
+	pending := doc.Bank.Statement.Transactions[2]
+	assert.Equal(t, "tx_00008zL2INM3xZ41THuRF3", pending.FITID)
+	assert.Equal(t, "Pending.", pending.Memo)
+	assert.Equal(t, 32, len(pending.Name))
+	assert.Equal(t, pending.DTUser, pending.DTPosted)
+}
+
+func TestWriteQIF(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, WriteQIF(&buf, account, transactions, Options{IncludePending: true}))
+
+	assert.Equal(t, `!Account
+NPeter Pan's Account
+TBank
+D04-00-04 12345678
+^
+!Type:Bank
+D21/08/2015
+T100.00
+PMondo
+Lmondo
+C*
+^
+D23/08/2015
+T-5.10
+PThe De Beauvoir Deli Co.
+MSalmon sandwich 🍞
+Leating_out
+C*
+^
+D23/08/2015
+T-6.79
+PVue Cinemas Islington Multiplex Screen
+Lentertainment
+^
+`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, WriteQIF(&buf, account, transactions, Options{QIFDateFormat: "01/02/2006"}))
+	assert.Contains(t, buf.String(), "D08/23/2015")
+	assert.NotContains(t, buf.String(), "Vue")
+
+	assert.Error(t, WriteQIF(&buf, account, []mondo.Transaction{{ID: "tx_1", Created: "yesterday"}}, Options{}))
+}
@@ -0,0 +1,121 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/sjwhitworth/gomondo/internal/mondoutil"
+)
+
+// Journals record the Mondo ID of every transaction under this metadata key, which ExportedIDs looks for.
+const idKey = "mondo_id"
+
+// exportedIDPattern matches the ID metadata written to both ledger and beancount journals.
+var exportedIDPattern = regexp.MustCompile(`^\s*;?\s*` + idKey + `:\s*"?([A-Za-z0-9_]+)"?\s*$`)
+
+// ExportedIDs scans an existing ledger or beancount journal for the IDs of transactions that were exported to it. Pass them as Options.SkipIDs to append new transactions to the journal without duplicating old ones. Only append settled transactions to a journal: one appended while pending would be skipped once it settled, leaving its pending amount and date in the journal.
+func ExportedIDs(r io.Reader) (map[string]bool, error) {
+	ids := map[string]bool{}
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		if m := exportedIDPattern.FindStringSubmatch(sc.Text()); m != nil {
+			ids[m[1]] = true
+		}
+	}
+
+	return ids, sc.Err()
+}
+
+// metadata returns the metadata recorded against a journal entry, in a stable order.
+func metadata(t mondo.Transaction) [][2]string {
+	md := [][2]string{{idKey, t.ID}}
+	if t.Merchant.Emoji != "" {
+		md = append(md, [2]string{"merchant_emoji", t.Merchant.Emoji})
+	}
+	if t.Notes != "" {
+		md = append(md, [2]string{"notes", t.Notes})
+	}
+	if t.Category != "" {
+		md = append(md, [2]string{"category", t.Category})
+	}
+	return md
+}
+
+// oneLine replaces newlines, which would break the journal's syntax, with spaces.
+func oneLine(s string) string {
+	return strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(s)
+}
+
+func currency(t mondo.Transaction) string {
+	if t.Currency == "" {
+		return "GBP"
+	}
+	return t.Currency
+}
+
+// WriteLedger writes transactions to w as ledger-cli journal entries, booking each one between accounts.AssetAccount and the account chosen by accounts. Pending transactions are marked with !, and settled ones with *.
+func WriteLedger(w io.Writer, transactions []mondo.Transaction, accounts *AccountMap, opts Options) error {
+	entries, err := prepare(transactions, opts)
+	if err != nil {
+		return err
+	}
+
+	if accounts == nil {
+		accounts = DefaultAccountMap()
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, e := range entries {
+		flag := "*"
+		if e.pending() {
+			flag = "!"
+		}
+
+		fmt.Fprintf(bw, "%v %v %v\n", e.created.Format("2006/01/02"), flag, oneLine(payee(e.Transaction)))
+		for _, kv := range metadata(e.Transaction) {
+			fmt.Fprintf(bw, "    ; %v: %v\n", kv[0], oneLine(kv[1]))
+		}
+		// Ledger requires at least two spaces between an account and its amount.
+		fmt.Fprintf(bw, "    %-38v  %v %v\n", accounts.Account(e.Transaction), mondoutil.FormatDecimal(-e.Amount, e.Currency), currency(e.Transaction))
+		fmt.Fprintf(bw, "    %-38v  %v %v\n", accounts.AssetAccount, mondoutil.FormatDecimal(e.Amount, e.Currency), currency(e.Transaction))
+		bw.WriteString("\n")
+	}
+
+	return bw.Flush()
+}
+
+// WriteBeancount writes transactions to w as beancount entries, booking each one between accounts.AssetAccount and the account chosen by accounts. Pending transactions are flagged with !, and settled ones with *. The accounts used must be opened elsewhere in the beancount file.
+func WriteBeancount(w io.Writer, transactions []mondo.Transaction, accounts *AccountMap, opts Options) error {
+	entries, err := prepare(transactions, opts)
+	if err != nil {
+		return err
+	}
+
+	if accounts == nil {
+		accounts = DefaultAccountMap()
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, e := range entries {
+		flag := "*"
+		if e.pending() {
+			flag = "!"
+		}
+
+		fmt.Fprintf(bw, "%v %v %v %v\n", e.created.Format("2006-01-02"), flag, strconv.Quote(oneLine(payee(e.Transaction))), strconv.Quote(oneLine(e.Description)))
+		for _, kv := range metadata(e.Transaction) {
+			fmt.Fprintf(bw, "  %v: %v\n", kv[0], strconv.Quote(oneLine(kv[1])))
+		}
+		fmt.Fprintf(bw, "  %-38v  %v %v\n", accounts.Account(e.Transaction), mondoutil.FormatDecimal(-e.Amount, e.Currency), currency(e.Transaction))
+		fmt.Fprintf(bw, "  %-38v  %v %v\n", accounts.AssetAccount, mondoutil.FormatDecimal(e.Amount, e.Currency), currency(e.Transaction))
+		bw.WriteString("\n")
+	}
+
+	return bw.Flush()
+}
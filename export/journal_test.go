@@ -0,0 +1,91 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/stretchr/testify/assert"
+)
+
+const rules = `{
+  "asset_account": "Assets:Mondo",
+  "categories": {
+    "eating_out": "Expenses:Food:EatingOut",
+    "mondo": "Assets:Current"
+  },
+  "merchants": [
+    {"match": "(?i)vue", "account": "Expenses:Fun:Cinema"}
+  ]
+}`
+
+func TestAccountMap(t *testing.T) {
+	m, err := ParseAccountMap(strings.NewReader(rules))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Assets:Current", m.Account(transactions[2]))
+	assert.Equal(t, "Expenses:Food:EatingOut", m.Account(transactions[1]))
+	assert.Equal(t, "Expenses:Fun:Cinema", m.Account(transactions[0]))
+	assert.Equal(t, "Expenses:Uncategorised", m.Account(mondo.Transaction{Amount: -1}))
+	assert.Equal(t, "Income:Uncategorised", m.Account(mondo.Transaction{Amount: 1}))
+
+	_, err = ParseAccountMap(strings.NewReader(`{"merchants": [{"match": "(", "account": "Expenses:Broken"}]}`))
+	assert.Error(t, err)
+}
+
+func TestWriteLedger(t *testing.T) {
+	m, err := ParseAccountMap(strings.NewReader(rules))
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteLedger(&buf, transactions, m, Options{}))
+	assert.Equal(t, `2015/08/21 * Mondo
+    ; mondo_id: tx_00008zjky19HyFLAzlUk7t
+    ; category: mondo
+    Assets:Current                          -100.00 GBP
+    Assets:Mondo                            100.00 GBP
+
+2015/08/22 * The De Beauvoir Deli Co.
+    ; mondo_id: tx_00008zIcpb1TB4yeIFXMzx
+    ; notes: Salmon sandwich 🍞
+    ; category: eating_out
+    Expenses:Food:EatingOut                 5.10 GBP
+    Assets:Mondo                            -5.10 GBP
+
+`, buf.String())
+}
+
+func TestWriteBeancountAppend(t *testing.T) {
+	m, err := ParseAccountMap(strings.NewReader(rules))
+	assert.NoError(t, err)
+
+	// Export the settled transactions first.
+	var journal bytes.Buffer
+	assert.NoError(t, WriteBeancount(&journal, transactions, m, Options{}))
+	assert.Contains(t, journal.String(), `2015-08-22 * "The De Beauvoir Deli Co." ""
+  mondo_id: "tx_00008zIcpb1TB4yeIFXMzx"
+  notes: "Salmon sandwich 🍞"
+  category: "eating_out"
+  Expenses:Food:EatingOut                 5.10 GBP
+  Assets:Mondo                            -5.10 GBP
+`)
+
+	// Then append, including the pending one, without duplicating what is already there.
+	ids, err := ExportedIDs(bytes.NewReader(journal.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(ids))
+
+	var appended bytes.Buffer
+	assert.NoError(t, WriteBeancount(&appended, transactions, m, Options{IncludePending: true, SkipIDs: ids}))
+	assert.Equal(t, 1, strings.Count(appended.String(), "mondo_id"))
+	assert.Contains(t, appended.String(), `2015-08-23 ! "Vue Cinemas Islington Multiplex Screen" ""`)
+	assert.Contains(t, appended.String(), "Expenses:Fun:Cinema")
+
+	// Ledger journals are recognised too.
+	var ledger bytes.Buffer
+	assert.NoError(t, WriteLedger(&ledger, transactions, nil, Options{IncludePending: true}))
+	ids, err = ExportedIDs(&ledger)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(ids))
+}
@@ -0,0 +1,165 @@
+package export
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/sjwhitworth/gomondo/internal/mondoutil"
+)
+
+const ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="211" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+`
+
+// The maximum length of the NAME field of an OFX transaction.
+const ofxMaxNameLength = 32
+
+type ofxDocument struct {
+	XMLName xml.Name `xml:"OFX"`
+	SignOn  ofxSignOn
+	Bank    ofxBank
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxSignOn struct {
+	XMLName  xml.Name  `xml:"SIGNONMSGSRSV1"`
+	Status   ofxStatus `xml:"SONRS>STATUS"`
+	DTServer string    `xml:"SONRS>DTSERVER"`
+	Language string    `xml:"SONRS>LANGUAGE"`
+}
+
+type ofxBank struct {
+	XMLName   xml.Name     `xml:"BANKMSGSRSV1"`
+	TrnUID    string       `xml:"STMTTRNRS>TRNUID"`
+	Status    ofxStatus    `xml:"STMTTRNRS>STATUS"`
+	Statement ofxStatement `xml:"STMTTRNRS>STMTRS"`
+}
+
+type ofxStatement struct {
+	CurDef       string           `xml:"CURDEF"`
+	BankID       string           `xml:"BANKACCTFROM>BANKID"`
+	AcctID       string           `xml:"BANKACCTFROM>ACCTID"`
+	AcctType     string           `xml:"BANKACCTFROM>ACCTTYPE"`
+	DTStart      string           `xml:"BANKTRANLIST>DTSTART"`
+	DTEnd        string           `xml:"BANKTRANLIST>DTEND"`
+	Transactions []ofxTransaction `xml:"BANKTRANLIST>STMTTRN"`
+	BalAmt       string           `xml:"LEDGERBAL>BALAMT"`
+	BalDTAsOf    string           `xml:"LEDGERBAL>DTASOF"`
+}
+
+type ofxTransaction struct {
+	TrnType  string `xml:"TRNTYPE"`
+	DTPosted string `xml:"DTPOSTED"`
+	DTUser   string `xml:"DTUSER"`
+	TrnAmt   string `xml:"TRNAMT"`
+	FITID    string `xml:"FITID"`
+	Name     string `xml:"NAME,omitempty"`
+	Memo     string `xml:"MEMO,omitempty"`
+}
+
+// ofxTime formats a time as an OFX datetime in UTC.
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:UTC]"
+}
+
+// WriteOFX writes transactions from account to w as an OFX 2.1.1 bank statement. Each transaction's FITID is its Mondo ID, so importing overlapping statements does not create duplicates. Pending transactions are only included if opts.IncludePending is set, in which case they are posted on the date they were created and marked as pending in their memo.
+func WriteOFX(w io.Writer, account mondo.Account, transactions []mondo.Transaction, opts Options) error {
+	entries, err := prepare(transactions, opts)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	stmt := ofxStatement{
+		CurDef:    "GBP",
+		BankID:    strings.Replace(account.SortCode, "-", "", -1),
+		AcctID:    account.AccountNumber,
+		AcctType:  "CHECKING",
+		DTStart:   ofxTime(now),
+		DTEnd:     ofxTime(now),
+		BalAmt:    mondoutil.FormatDecimal(0, "GBP"),
+		BalDTAsOf: ofxTime(now),
+	}
+
+	if len(entries) > 0 {
+		// The statement must cover the date every transaction was posted on, which is when it settled.
+		start, end := entries[0].posted(), entries[0].posted()
+		for _, e := range entries {
+			if e.posted().Before(start) {
+				start = e.posted()
+			}
+			if e.posted().After(end) {
+				end = e.posted()
+			}
+		}
+		stmt.DTStart = ofxTime(start)
+		stmt.DTEnd = ofxTime(end)
+
+		last := entries[len(entries)-1]
+		stmt.BalAmt = mondoutil.FormatDecimal(last.AccountBalance, last.Currency)
+		stmt.BalDTAsOf = ofxTime(last.created)
+		if last.Currency != "" {
+			stmt.CurDef = last.Currency
+		}
+	}
+
+	for _, e := range entries {
+		trnType := "DEBIT"
+		if e.Amount > 0 {
+			trnType = "CREDIT"
+		}
+
+		name := payee(e.Transaction)
+		if len([]rune(name)) > ofxMaxNameLength {
+			name = string([]rune(name)[:ofxMaxNameLength])
+		}
+
+		memo := e.Notes
+		if e.pending() {
+			memo = strings.TrimSpace("Pending. " + memo)
+		}
+
+		stmt.Transactions = append(stmt.Transactions, ofxTransaction{
+			TrnType:  trnType,
+			DTPosted: ofxTime(e.posted()),
+			DTUser:   ofxTime(e.created),
+			TrnAmt:   mondoutil.FormatDecimal(e.Amount, e.Currency),
+			FITID:    e.ID,
+			Name:     name,
+			Memo:     memo,
+		})
+	}
+
+	doc := ofxDocument{
+		SignOn: ofxSignOn{
+			Status:   ofxStatus{Code: 0, Severity: "INFO"},
+			DTServer: ofxTime(now),
+			Language: "ENG",
+		},
+		Bank: ofxBank{
+			TrnUID:    "0",
+			Status:    ofxStatus{Code: 0, Severity: "INFO"},
+			Statement: stmt,
+		},
+	}
+
+	if _, err := io.WriteString(w, ofxHeader); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
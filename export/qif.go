@@ -0,0 +1,58 @@
+package export
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/sjwhitworth/gomondo/internal/mondoutil"
+)
+
+// WriteQIF writes transactions from account to w as a QIF bank file, preceded by an account header so that importers can pick the right account. Settled transactions are marked as cleared. Pending transactions are only included if opts.IncludePending is set, in which case they are left uncleared and dated when they were created.
+func WriteQIF(w io.Writer, account mondo.Account, transactions []mondo.Transaction, opts Options) error {
+	entries, err := prepare(transactions, opts)
+	if err != nil {
+		return err
+	}
+
+	layout := opts.QIFDateFormat
+	if layout == "" {
+		layout = DefaultQIFDateFormat
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("!Account\n")
+	writeQIFField(bw, 'N', account.Description)
+	writeQIFField(bw, 'T', "Bank")
+	if account.SortCode != "" || account.AccountNumber != "" {
+		writeQIFField(bw, 'D', strings.TrimSpace(account.SortCode+" "+account.AccountNumber))
+	}
+	bw.WriteString("^\n")
+
+	bw.WriteString("!Type:Bank\n")
+	for _, e := range entries {
+		writeQIFField(bw, 'D', e.posted().Format(layout))
+		writeQIFField(bw, 'T', mondoutil.FormatDecimal(e.Amount, e.Currency))
+		writeQIFField(bw, 'P', payee(e.Transaction))
+		if e.Notes != "" {
+			writeQIFField(bw, 'M', e.Notes)
+		}
+		if e.Category != "" {
+			writeQIFField(bw, 'L', e.Category)
+		}
+		if !e.pending() {
+			writeQIFField(bw, 'C', "*")
+		}
+		bw.WriteString("^\n")
+	}
+
+	return bw.Flush()
+}
+
+// writeQIFField writes a single field. QIF fields are line based, so newlines in values are replaced with spaces.
+func writeQIFField(w *bufio.Writer, code byte, value string) {
+	w.WriteByte(code)
+	w.WriteString(strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(value))
+	w.WriteByte('\n')
+}
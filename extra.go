@@ -0,0 +1,165 @@
+package mondo
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// The domain types below keep any fields the API sends that they do not know about in Extra, as raw JSON keyed by field name, so that new fields can be read before this package is updated. Extra is written back out after the known fields when an object is marshalled, so storing or forwarding objects does not lose them. Fields are known if the type has a field of that name, matched regardless of case as encoding/json does, and an entry in Extra with a known name is never written over the field.
+
+func (t *Transaction) UnmarshalJSON(b []byte) error {
+	type plain Transaction
+	if err := json.Unmarshal(b, (*plain)(t)); err != nil {
+		return err
+	}
+	return unknownFields(b, t, &t.Extra)
+}
+
+func (t Transaction) MarshalJSON() ([]byte, error) {
+	type plain Transaction
+	return marshalWithExtra(plain(t), t.Extra)
+}
+
+func (m *Merchant) UnmarshalJSON(b []byte) error {
+	type plain Merchant
+	if err := json.Unmarshal(b, (*plain)(m)); err != nil {
+		return err
+	}
+	return unknownFields(b, m, &m.Extra)
+}
+
+func (m Merchant) MarshalJSON() ([]byte, error) {
+	type plain Merchant
+	return marshalWithExtra(plain(m), m.Extra)
+}
+
+func (a *Account) UnmarshalJSON(b []byte) error {
+	type plain Account
+	if err := json.Unmarshal(b, (*plain)(a)); err != nil {
+		return err
+	}
+	return unknownFields(b, a, &a.Extra)
+}
+
+func (a Account) MarshalJSON() ([]byte, error) {
+	type plain Account
+	return marshalWithExtra(plain(a), a.Extra)
+}
+
+func (w *Webhook) UnmarshalJSON(b []byte) error {
+	type plain Webhook
+	if err := json.Unmarshal(b, (*plain)(w)); err != nil {
+		return err
+	}
+	return unknownFields(b, w, &w.Extra)
+}
+
+func (w Webhook) MarshalJSON() ([]byte, error) {
+	type plain Webhook
+	return marshalWithExtra(plain(w), w.Extra)
+}
+
+func (a *Attachment) UnmarshalJSON(b []byte) error {
+	type plain Attachment
+	if err := json.Unmarshal(b, (*plain)(a)); err != nil {
+		return err
+	}
+	return unknownFields(b, a, &a.Extra)
+}
+
+func (a Attachment) MarshalJSON() ([]byte, error) {
+	type plain Attachment
+	return marshalWithExtra(plain(a), a.Extra)
+}
+
+// knownFields caches the JSON field names of each type, lower cased, as encoding/json matches them regardless of case.
+var knownFields sync.Map
+
+func fieldNames(t reflect.Type) map[string]bool {
+	if names, ok := knownFields.Load(t); ok {
+		return names.(map[string]bool)
+	}
+
+	names := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" || f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		names[strings.ToLower(name)] = true
+	}
+
+	knownFields.Store(t, names)
+	return names
+}
+
+// unknownFields sets extra to the fields of the JSON object b that v has no field for, or nil if there are none.
+func unknownFields(b []byte, v interface{}, extra *map[string]json.RawMessage) error {
+	*extra = nil
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+
+	known := fieldNames(reflect.TypeOf(v).Elem())
+	for k := range fields {
+		if known[strings.ToLower(k)] {
+			delete(fields, k)
+		}
+	}
+
+	if len(fields) > 0 {
+		*extra = fields
+	}
+	return nil
+}
+
+// marshalWithExtra marshals v, a struct, followed by the fields in extra that it does not already have, in order of name.
+func marshalWithExtra(v interface{}, extra map[string]json.RawMessage) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return b, err
+	}
+
+	known := fieldNames(reflect.TypeOf(v))
+	var keys []string
+	for k := range extra {
+		if !known[strings.ToLower(k)] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.Write(b[:len(b)-1])
+	for _, k := range keys {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+
+		value := extra[k]
+		if len(value) == 0 {
+			value = json.RawMessage("null")
+		}
+		if err := json.Compact(&buf, value); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,91 @@
+package mondo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnknownFieldsRoundTrip(t *testing.T) {
+	in := `{
+		"id": "tx_1",
+		"amount": -510,
+		"decline_reason": "INSUFFICIENT_FUNDS",
+		"counterparty": {"name": "Peter", "sort_code": "040004"},
+		"merchant": {"id": "merch_1", "name": "Deli", "disable_feedback": true, "address": {"city": "London"}},
+		"fx": [1, "two", null]
+	}`
+
+	var tx Transaction
+	assert.NoError(t, json.Unmarshal([]byte(in), &tx))
+	assert.Equal(t, "tx_1", tx.ID)
+	assert.Equal(t, -510, tx.Amount)
+	assert.Equal(t, "London", tx.Merchant.Address.City)
+
+	assert.Len(t, tx.Extra, 3)
+	assert.Equal(t, `"INSUFFICIENT_FUNDS"`, string(tx.Extra["decline_reason"]))
+	assert.JSONEq(t, `{"name": "Peter", "sort_code": "040004"}`, string(tx.Extra["counterparty"]))
+	assert.Equal(t, map[string]json.RawMessage{"disable_feedback": json.RawMessage("true")}, tx.Merchant.Extra)
+
+	out, err := json.Marshal(tx)
+	assert.NoError(t, err)
+
+	var fields map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out, &fields))
+	assert.Equal(t, "INSUFFICIENT_FUNDS", fields["decline_reason"])
+	assert.Equal(t, []interface{}{1.0, "two", nil}, fields["fx"])
+	assert.Equal(t, true, fields["merchant"].(map[string]interface{})["disable_feedback"])
+
+	// Unmarshalling what was marshalled, and marshalling it again, loses nothing.
+	var again Transaction
+	assert.NoError(t, json.Unmarshal(out, &again))
+	assert.Len(t, again.Extra, 3)
+	out2, err := json.Marshal(again)
+	assert.NoError(t, err)
+	assert.Equal(t, string(out), string(out2))
+}
+
+func TestUnknownFieldsNone(t *testing.T) {
+	var ac Account
+	assert.NoError(t, json.Unmarshal([]byte(`{"id": "acc_1", "Description": "Peter Pan's Account", "created": "2015-08-22T12:20:18Z"}`), &ac))
+	assert.Nil(t, ac.Extra)
+	assert.Equal(t, "Peter Pan's Account", ac.Description)
+
+	// Reusing a value does not keep fields from before.
+	var w Webhook
+	assert.NoError(t, json.Unmarshal([]byte(`{"id": "webhook_1", "secret": "s"}`), &w))
+	assert.Len(t, w.Extra, 1)
+	assert.NoError(t, json.Unmarshal([]byte(`{"id": "webhook_2"}`), &w))
+	assert.Nil(t, w.Extra)
+
+	out, err := json.Marshal(Webhook{Id: "webhook_1"})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"account_id":"","id":"webhook_1","url":""}`, string(out))
+}
+
+func TestUnknownFieldsMarshal(t *testing.T) {
+	// Extra fields that clash with known ones are not written twice, and empty values are written as null.
+	a := Attachment{Id: "attach_1", Extra: map[string]json.RawMessage{
+		"id":       json.RawMessage(`"other"`),
+		"size":     json.RawMessage(` { "bytes" : 10 } `),
+		"scanned":  nil,
+		"a\"quote": json.RawMessage(`1`),
+	}}
+
+	out, err := json.Marshal(a)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":"attach_1","user_id":"","external_id":"","file_url":"","file_type":"","created":"","a\"quote":1,"scanned":null,"size":{"bytes":10}}`, string(out))
+
+	var back Attachment
+	assert.NoError(t, json.Unmarshal(out, &back))
+	assert.Equal(t, "attach_1", back.Id)
+	assert.Equal(t, json.RawMessage(`1`), back.Extra[`a"quote`])
+	assert.Len(t, back.Extra, 3)
+
+	// Keys are matched once unescaped, and regardless of case, as encoding/json matches them to fields.
+	assert.NoError(t, json.Unmarshal([]byte(`{"\u0069d": "attach_2", "File_Type": "image/png", "\u0073ize" : 10}`), &back))
+	assert.Equal(t, "attach_2", back.Id)
+	assert.Equal(t, "image/png", back.FileType)
+	assert.Equal(t, map[string]json.RawMessage{"size": json.RawMessage("10")}, back.Extra)
+}
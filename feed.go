@@ -0,0 +1,195 @@
+package mondo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+)
+
+// The basic feed item type, which shows a title, body and image. It is the only type Mondo documents so far.
+const FeedItemTypeBasic = "basic"
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// FeedItem is an item to post to a user's feed. Build one with NewFeedItem and its With methods, or fill in the fields directly, then post it with PostFeedItem.
+//
+//	item := mondo.NewFeedItem("Lunch budget").
+//	  WithBody("You have £12.50 left this week").
+//	  WithImage("https://example.com/icon.png").
+//	  WithURL("https://example.com/budgets")
+type FeedItem struct {
+	// Type is the type of feed item. Defaults to FeedItemTypeBasic.
+	Type string
+
+	Title    string
+	Body     string
+	ImageURL string
+
+	// URL is opened when the user taps the feed item.
+	URL string
+
+	// Colours are hex codes, such as #FCF1EE. Colours left empty are chosen by Mondo.
+	BackgroundColor string
+	BodyColor       string
+	TitleColor      string
+
+	// Params holds extra params to send, for types and params this package does not know about yet. They override the params set by other fields.
+	Params map[string]string
+}
+
+// NewFeedItem returns a basic feed item with a title.
+func NewFeedItem(title string) *FeedItem {
+	return &FeedItem{Type: FeedItemTypeBasic, Title: title}
+}
+
+// WithBody sets the body text of the feed item.
+func (f *FeedItem) WithBody(body string) *FeedItem {
+	f.Body = body
+	return f
+}
+
+// WithImage sets the URL of the image shown alongside the feed item.
+func (f *FeedItem) WithImage(imageURL string) *FeedItem {
+	f.ImageURL = imageURL
+	return f
+}
+
+// WithURL sets the URL opened when the feed item is tapped.
+func (f *FeedItem) WithURL(URL string) *FeedItem {
+	f.URL = URL
+	return f
+}
+
+// WithColors sets the background, body and title colours of the feed item. Empty colours are left for Mondo to choose.
+func (f *FeedItem) WithColors(background, body, title string) *FeedItem {
+	f.BackgroundColor = background
+	f.BodyColor = body
+	f.TitleColor = title
+	return f
+}
+
+// WithParam sets an extra param, for types and params this package does not know about yet.
+func (f *FeedItem) WithParam(key, value string) *FeedItem {
+	if f.Params == nil {
+		f.Params = map[string]string{}
+	}
+	f.Params[key] = value
+	return f
+}
+
+// Validate checks that the feed item can be posted: a basic item needs a title, colours must be hex codes and URLs must be absolute http or https URLs.
+func (f *FeedItem) Validate() error {
+	if f.kind() == FeedItemTypeBasic && f.Title == "" {
+		return fmt.Errorf("title cannot be empty")
+	}
+
+	colors := []struct{ name, value string }{
+		{"background colour", f.BackgroundColor},
+		{"body colour", f.BodyColor},
+		{"title colour", f.TitleColor},
+	}
+	for _, c := range colors {
+		if c.value != "" && !hexColor.MatchString(c.value) {
+			return fmt.Errorf("%v %q is not a hex colour such as #FCF1EE", c.name, c.value)
+		}
+	}
+
+	urls := []struct{ name, value string }{
+		{"image URL", f.ImageURL},
+		{"URL", f.URL},
+	}
+	for _, u := range urls {
+		if u.value == "" {
+			continue
+		}
+		parsed, err := url.Parse(u.value)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("%v %q is not an absolute http or https URL", u.name, u.value)
+		}
+	}
+
+	return nil
+}
+
+func (f *FeedItem) kind() string {
+	if f.Type == "" {
+		return FeedItemTypeBasic
+	}
+	return f.Type
+}
+
+// params returns the form params the feed item is posted with.
+func (f *FeedItem) params(accountId string) map[string]string {
+	params := map[string]string{
+		"account_id": accountId,
+		"type":       f.kind(),
+	}
+
+	if f.URL != "" {
+		params["url"] = f.URL
+	}
+
+	fields := []struct{ key, value string }{
+		{"title", f.Title},
+		{"body", f.Body},
+		{"image_url", f.ImageURL},
+		{"background_color", f.BackgroundColor},
+		{"body_color", f.BodyColor},
+		{"title_color", f.TitleColor},
+	}
+	for _, field := range fields {
+		if field.value != "" {
+			params[fmt.Sprintf("params[%s]", field.key)] = field.value
+		}
+	}
+
+	for k, v := range f.Params {
+		params[fmt.Sprintf("params[%s]", k)] = v
+	}
+
+	return params
+}
+
+// PostFeedItem validates a feed item and posts it to the feed of an account.
+// TODO: There is no way to delete a feed item currently, so use with caution.
+func (m *MondoClient) PostFeedItem(accountId string, item *FeedItem) (err error) {
+	type feedItemResponse struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+
+	if accountId == "" {
+		return fmt.Errorf("accountId cannot be empty")
+	}
+
+	if item == nil {
+		return fmt.Errorf("item cannot be nil")
+	}
+
+	if err := item.Validate(); err != nil {
+		return err
+	}
+
+	c := m.startCall("PostFeedItem")
+	defer func() { c.end(err) }()
+	c.setAccount(accountId)
+
+	resp, err := m.callWithAuth(c, "POST", "feed", item.params(accountId))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var fresp feedItemResponse
+	if err := json.NewDecoder(resp.Body).Decode(&fresp); err != nil {
+		return err
+	}
+
+	// Generate a nicely formatted error code back to the caller
+	if fresp.Code != "" {
+		return fmt.Errorf("%v: %v", fresp.Code, fresp.Message)
+	}
+
+	return nil
+}
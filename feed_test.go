@@ -0,0 +1,52 @@
+package mondo
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeedItemValidate(t *testing.T) {
+	assert.NoError(t, NewFeedItem("Hello!").Validate())
+	assert.NoError(t, NewFeedItem("Hello!").WithColors("#FCF1EE", "#333", "").WithURL("https://example.com/a?b=c").Validate())
+	assert.NoError(t, (&FeedItem{Type: "future_type"}).Validate())
+
+	assert.Error(t, NewFeedItem("").Validate())
+	assert.Error(t, NewFeedItem("Hello!").WithColors("red", "", "").Validate())
+	assert.Error(t, NewFeedItem("Hello!").WithColors("", "#12345", "").Validate())
+	assert.Error(t, NewFeedItem("Hello!").WithImage("gopher.png").Validate())
+	assert.Error(t, NewFeedItem("Hello!").WithURL("javascript:alert(1)").Validate())
+}
+
+func TestPostFeedItem(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/feed",
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "POST", r.Method)
+			assert.Equal(t, "account1", r.FormValue("account_id"))
+			assert.Equal(t, "basic", r.FormValue("type"))
+			assert.Equal(t, "https://example.com/budgets", r.FormValue("url"))
+			assert.Equal(t, "Lunch budget", r.FormValue("params[title]"))
+			assert.Equal(t, "#FFFFFF", r.FormValue("params[background_color]"))
+			assert.Equal(t, "extra", r.FormValue("params[subtitle]"))
+			_, sent := r.PostForm["params[image_url]"]
+			assert.False(t, sent)
+			fmt.Fprint(w, `{}`)
+		},
+	)
+
+	client, err := Authenticate("some", "valid", "credentials", "here")
+	assert.NoError(t, err)
+
+	item := NewFeedItem("Lunch budget").
+		WithURL("https://example.com/budgets").
+		WithColors("#FFFFFF", "", "").
+		WithParam("subtitle", "extra")
+	assert.NoError(t, client.PostFeedItem("account1", item))
+
+	assert.Error(t, client.PostFeedItem("account1", NewFeedItem("Bad").WithColors("white", "", "")))
+}
@@ -0,0 +1,52 @@
+// Package mondoutil holds helpers shared by the packages and commands in this module: formatting amounts of money, and remembering keys in a file.
+package mondoutil
+
+import "fmt"
+
+// zeroDecimalCurrencies are currencies that have no minor unit, so their amounts are already in whole units.
+var zeroDecimalCurrencies = map[string]bool{
+	"JPY": true,
+	"KRW": true,
+	"ISK": true,
+}
+
+// FormatDecimal formats an amount in minor units, such as pence, as a plain decimal such as -5.10. Amounts in currencies without a minor unit, such as yen, have no decimal point.
+func FormatDecimal(amount int, currency string) string {
+	if zeroDecimalCurrencies[currency] {
+		return fmt.Sprintf("%d", amount)
+	}
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%v%d.%02d", sign, amount/100, amount%100)
+}
+
+// FormatAmount formats an amount in minor units with the symbol of its currency, such as -£5.10. Currencies without a symbol are prefixed with their code, such as JPY 500. An empty currency is taken to be GBP.
+func FormatAmount(amount int, currency string) string {
+	symbol := currency + " "
+	switch currency {
+	case "GBP", "":
+		symbol = "£"
+	case "EUR":
+		symbol = "€"
+	case "USD":
+		symbol = "$"
+	}
+
+	decimal := FormatDecimal(amount, currency)
+	if amount < 0 {
+		return "-" + symbol + decimal[1:]
+	}
+	return symbol + decimal
+}
+
+// Major converts an amount in minor units to whole units of its currency, such as pence to pounds.
+func Major(amount int, currency string) float64 {
+	if zeroDecimalCurrencies[currency] {
+		return float64(amount)
+	}
+	return float64(amount) / 100
+}
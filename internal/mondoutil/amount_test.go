@@ -0,0 +1,39 @@
+package mondoutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		amount   int
+		currency string
+		decimal  string
+		display  string
+	}{
+		{-510, "GBP", "-5.10", "-£5.10"},
+		{510, "GBP", "5.10", "£5.10"},
+		{0, "GBP", "0.00", "£0.00"},
+		{-5, "GBP", "-0.05", "-£0.05"},
+		{123456789, "GBP", "1234567.89", "£1234567.89"},
+		{-99, "", "-0.99", "-£0.99"},
+		{2000, "EUR", "20.00", "€20.00"},
+		{-1999, "USD", "-19.99", "-$19.99"},
+		{-1500, "JPY", "-1500", "-JPY 1500"},
+		{1500, "KRW", "1500", "KRW 1500"},
+		{-250, "CHF", "-2.50", "-CHF 2.50"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.decimal, FormatDecimal(test.amount, test.currency), "%v %v", test.amount, test.currency)
+		assert.Equal(t, test.display, FormatAmount(test.amount, test.currency), "%v %v", test.amount, test.currency)
+	}
+}
+
+func TestMajor(t *testing.T) {
+	assert.Equal(t, -5.1, Major(-510, "GBP"))
+	assert.Equal(t, 20.0, Major(2000, ""))
+	assert.Equal(t, 1500.0, Major(1500, "JPY"))
+}
@@ -0,0 +1,95 @@
+package mondoutil
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// KeyStore is a set of keys persisted to a JSON file, used to remember what has already been done, such as which alerts have been posted, across restarts. It is safe for concurrent use.
+type KeyStore struct {
+	path string
+
+	mu   sync.Mutex
+	keys map[string]bool
+}
+
+// OpenKeyStore loads a KeyStore from path. The file is created when the first key is added.
+func OpenKeyStore(path string) (*KeyStore, error) {
+	s := &KeyStore{path: path, keys: map[string]bool{}}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	if err := json.Unmarshal(b, &keys); err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		s.keys[k] = true
+	}
+
+	return s, nil
+}
+
+// Has reports whether key has been added.
+func (s *KeyStore) Has(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys[key], nil
+}
+
+// Add records key, and rewrites the file atomically, so that a crash never leaves it half written.
+func (s *KeyStore) Add(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.keys[key] {
+		return nil
+	}
+	s.keys[key] = true
+
+	var keys []string
+	for k := range s.keys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		delete(s.keys, key)
+		return err
+	}
+
+	if err := writeFile(s.path, b); err != nil {
+		delete(s.keys, key)
+		return err
+	}
+	return nil
+}
+
+// writeFile replaces the file at path with b, by writing a temporary file alongside it and renaming that over it.
+func writeFile(path string, b []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
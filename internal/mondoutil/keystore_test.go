@@ -0,0 +1,52 @@
+package mondoutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "state.json")
+	s, err := OpenKeyStore(path)
+	assert.NoError(t, err)
+
+	// Nothing is written until a key is added.
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	has, err := s.Has("b")
+	assert.NoError(t, err)
+	assert.False(t, has)
+
+	assert.NoError(t, s.Add("b"))
+	assert.NoError(t, s.Add("a"))
+	assert.NoError(t, s.Add("b"))
+
+	b, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `["a", "b"]`, string(b))
+
+	// The keys are there when the store is opened again, and no temporary files are left behind.
+	s, err = OpenKeyStore(path)
+	assert.NoError(t, err)
+
+	has, err = s.Has("a")
+	assert.NoError(t, err)
+	assert.True(t, has)
+
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(files))
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("{"), 0600))
+	_, err = OpenKeyStore(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,47 @@
+package mondo
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// Logger receives a record of each request made to the API: its method, path, status, duration and retries. Successful requests are logged at debug level, error responses as warnings and requests that got no response as errors. Arguments are alternating keys and values, so a *slog.Logger can be used directly. Tokens, passwords and client secrets are redacted before they are logged.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// The Logger given to clients returned by Authenticate, which also logs the request Authenticate makes. Nothing is logged if it is nil.
+var DefaultLogger Logger
+
+// secrets matches credentials in URLs, form values, JSON and headers, capturing everything but the secret itself.
+var secrets = regexp.MustCompile(`(?i)((?:access_token|refresh_token|client_secret|password|token)"?\s*[:=]\s*"?|bearer\s+)[^&"\s,;}]+`)
+
+// redact replaces tokens, passwords and client secrets in s with REDACTED.
+func redact(s string) string {
+	return secrets.ReplaceAllString(s, "${1}REDACTED")
+}
+
+// logRequest logs the outcome of a request to the API.
+func logRequest(logger Logger, req *http.Request, resp *http.Response, err error, duration time.Duration, retries int) {
+	if logger == nil {
+		return
+	}
+
+	path := req.URL.Path
+	if req.URL.RawQuery != "" {
+		path += "?" + req.URL.RawQuery
+	}
+	args := []interface{}{"method", req.Method, "path", redact(path)}
+
+	switch {
+	case err != nil:
+		logger.Error("mondo: request failed", append(args, "duration", duration, "retries", retries, "error", redact(err.Error()))...)
+	case resp.StatusCode >= 400:
+		logger.Warn("mondo: request failed", append(args, "status", resp.StatusCode, "duration", duration, "retries", retries)...)
+	default:
+		logger.Debug("mondo: request", append(args, "status", resp.StatusCode, "duration", duration, "retries", retries)...)
+	}
+}
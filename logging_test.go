@@ -0,0 +1,56 @@
+package mondo
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var _ Logger = (*slog.Logger)(nil)
+
+func TestRedact(t *testing.T) {
+	for in, out := range map[string]string{
+		"client_id=a&client_secret=s3cret&password=hunter2": "client_id=a&client_secret=REDACTED&password=REDACTED",
+		`{"access_token": "abc.def", "user_id": "user_1"}`:  `{"access_token": "REDACTED", "user_id": "user_1"}`,
+		"Authorization: Bearer abc.def":                     "Authorization: Bearer REDACTED",
+		"/transactions?account_id=acc_1&limit=100":          "/transactions?account_id=acc_1&limit=100",
+	} {
+		assert.Equal(t, out, redact(in))
+	}
+}
+
+func TestLogging(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/balance",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(500)
+		},
+	)
+
+	var buf bytes.Buffer
+	DefaultLogger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	defer func() { DefaultLogger = nil }()
+
+	client, err := Authenticate("some", "valid", "credentials", "here")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultLogger, client.Logger)
+	assert.Contains(t, buf.String(), `level=DEBUG msg="mondo: request" method=POST path=/oauth2/token status=200`)
+	assert.NotContains(t, buf.String(), "valid")
+
+	buf.Reset()
+	client.Balance("account1")
+	assert.Contains(t, buf.String(), `level=WARN msg="mondo: request failed" method=GET path="/balance?account_id=account1" status=500 duration=`)
+	assert.Contains(t, buf.String(), "retries=0")
+
+	buf.Reset()
+	BaseMondoURL = "http://127.0.0.1:0/?access_token=abc"
+	client.Accounts()
+	assert.Contains(t, buf.String(), `level=ERROR msg="mondo: request failed" method=GET`)
+	assert.Contains(t, buf.String(), "access_token=REDACTED")
+	assert.NotContains(t, buf.String(), "abc")
+}
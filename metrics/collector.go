@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/sjwhitworth/gomondo/internal/mondoutil"
+)
+
+// Collector records account metrics: balances, spending and transaction counts. Amounts are exported in major units, such as pounds. It is safe for concurrent use.
+type Collector struct {
+	balance       *GaugeVec
+	spendToday    *GaugeVec
+	spend         *CounterVec
+	merchantSpend *CounterVec
+	transactions  *CounterVec
+
+	// Window is how long a transaction is remembered after it is first observed, so that it is not counted again. It must cover how long polling and webhook events can lag each other. Defaults to DefaultWindow.
+	Window time.Duration
+
+	mu   sync.Mutex
+	seen map[string]bool
+
+	// observed holds the IDs in seen in the order they were observed, so that the oldest can be forgotten once they leave the window.
+	observed *list.List
+	now      func() time.Time
+}
+
+// DefaultWindow is how long a Collector remembers transactions, unless it sets its own Window.
+var DefaultWindow = 24 * time.Hour
+
+// UnknownMerchant is the merchant label of spending that has no merchant, such as cash withdrawals.
+const UnknownMerchant = "unknown"
+
+type observation struct {
+	id string
+	at time.Time
+}
+
+// NewCollector registers account metrics, and returns a Collector that records them.
+func NewCollector(r *Registry) *Collector {
+	return &Collector{
+		balance:       r.NewGaugeVec("mondo_balance", "Balance of the account.", "account_id", "account", "currency"),
+		spendToday:    r.NewGaugeVec("mondo_spend_today", "Amount spent from the account today.", "account_id", "account", "currency"),
+		spend:         r.NewCounterVec("mondo_spend_total", "Amount spent, by category.", "account_id", "category", "currency"),
+		merchantSpend: r.NewCounterVec("mondo_merchant_spend_total", "Amount spent, by merchant.", "account_id", "merchant", "currency"),
+		transactions:  r.NewCounterVec("mondo_transactions_total", "Transactions seen, by category.", "account_id", "category"),
+		Window:        DefaultWindow,
+		seen:          map[string]bool{},
+		observed:      list.New(),
+		now:           time.Now,
+	}
+}
+
+// SetBalance records the balance of an account.
+func (c *Collector) SetBalance(account mondo.Account, balance *mondo.Balance) {
+	c.balance.Set(mondoutil.Major(balance.Balance, balance.Currency), account.ID, account.Description, balance.Currency)
+	c.spendToday.Set(mondoutil.Major(-balance.SpendToday, balance.Currency), account.ID, account.Description, balance.Currency)
+}
+
+// Observe counts a transaction on an account. Each transaction is only counted once, however many times it is observed within Window, so polling and webhook events can overlap.
+func (c *Collector) Observe(accountId string, tx mondo.Transaction) {
+	c.mu.Lock()
+	now := c.now()
+	c.forget(now.Add(-c.Window))
+	seen := c.seen[tx.ID]
+	if !seen {
+		c.seen[tx.ID] = true
+		c.observed.PushBack(observation{tx.ID, now})
+	}
+	c.mu.Unlock()
+
+	if seen {
+		return
+	}
+
+	c.transactions.Inc(accountId, tx.Category)
+	if tx.Amount >= 0 || tx.IsLoad {
+		return
+	}
+
+	// Descriptions vary from one transaction to the next, so they would make a new series each time.
+	merchant := tx.Merchant.Name
+	if merchant == "" {
+		merchant = UnknownMerchant
+	}
+	c.spend.Add(mondoutil.Major(-tx.Amount, tx.Currency), accountId, tx.Category, tx.Currency)
+	c.merchantSpend.Add(mondoutil.Major(-tx.Amount, tx.Currency), accountId, merchant, tx.Currency)
+}
+
+// forget drops the transactions first observed before cutoff. Must be called with c.mu held.
+func (c *Collector) forget(cutoff time.Time) {
+	for e := c.observed.Front(); e != nil; e = c.observed.Front() {
+		o := e.Value.(observation)
+		if !o.at.Before(cutoff) {
+			return
+		}
+		c.observed.Remove(e)
+		delete(c.seen, o.id)
+	}
+}
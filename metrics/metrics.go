@@ -0,0 +1,241 @@
+// Package metrics exposes Mondo account and API client metrics in the Prometheus text format.
+//
+// It has a small Registry of gauges, counters and histograms rather than depending on the Prometheus client library. A Collector turns balances and transactions into account metrics, and a Transport measures calls made to the API.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry holds metrics, and writes them in the Prometheus text format. It is safe for concurrent use.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []*metric
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+type metric struct {
+	name, help, kind string
+	labels           []string
+	buckets          []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// series is the value of a metric for one set of label values.
+type series struct {
+	values []string
+
+	// value is the value of a gauge or counter, and sum the sum of a histogram's observations.
+	value float64
+	sum   float64
+
+	// counts holds the number of observations in each bucket of a histogram, not cumulative, with a final bucket for +Inf.
+	counts []uint64
+	count  uint64
+}
+
+func (r *Registry) register(name, help, kind string, buckets []float64, labels []string) *metric {
+	m := &metric{name: name, help: help, kind: kind, labels: labels, buckets: buckets, series: map[string]*series{}}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.metrics {
+		if existing.name == name {
+			panic(fmt.Sprintf("metrics: %v registered twice", name))
+		}
+	}
+	r.metrics = append(r.metrics, m)
+	return m
+}
+
+// with returns the series for label values, creating it if need be. Must be called with m.mu held.
+func (m *metric) with(values []string) *series {
+	if len(values) != len(m.labels) {
+		panic(fmt.Sprintf("metrics: %v has %v labels but was given %v values", m.name, len(m.labels), len(values)))
+	}
+
+	key := strings.Join(values, "\xff")
+	s, ok := m.series[key]
+	if !ok {
+		s = &series{values: append([]string(nil), values...)}
+		if m.kind == "histogram" {
+			s.counts = make([]uint64, len(m.buckets)+1)
+		}
+		m.series[key] = s
+	}
+	return s
+}
+
+// GaugeVec is a metric that can go up and down, such as a balance, partitioned by labels.
+type GaugeVec struct {
+	m *metric
+}
+
+// NewGaugeVec registers a gauge.
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{r.register(name, help, "gauge", nil, labels)}
+}
+
+// Set sets the gauge for label values.
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	g.m.mu.Lock()
+	defer g.m.mu.Unlock()
+	g.m.with(labelValues).value = value
+}
+
+// CounterVec is a metric that only goes up, such as the number of transactions, partitioned by labels.
+type CounterVec struct {
+	m *metric
+}
+
+// NewCounterVec registers a counter.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{r.register(name, help, "counter", nil, labels)}
+}
+
+// Add increases the counter for label values. Negative values are ignored, as counters never go down.
+func (c *CounterVec) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		return
+	}
+	c.m.mu.Lock()
+	defer c.m.mu.Unlock()
+	c.m.with(labelValues).value += value
+}
+
+// Inc increases the counter for label values by one.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// HistogramVec counts observations, such as request latencies, in buckets, partitioned by labels.
+type HistogramVec struct {
+	m *metric
+}
+
+// The buckets used for latencies in seconds, unless a histogram is given its own.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// NewHistogramVec registers a histogram with upper bounds for its buckets, which defaults to DefaultBuckets if nil.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &HistogramVec{r.register(name, help, "histogram", buckets, labels)}
+}
+
+// Observe adds an observation for label values.
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	h.m.mu.Lock()
+	defer h.m.mu.Unlock()
+
+	s := h.m.with(labelValues)
+	i := sort.SearchFloat64s(h.m.buckets, value)
+	s.counts[i]++
+	s.count++
+	s.sum += value
+}
+
+// WriteTo writes every metric to w in the Prometheus text format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	metrics := append([]*metric(nil), r.metrics...)
+	r.mu.Unlock()
+
+	var written int64
+	for _, m := range metrics {
+		n, err := io.WriteString(w, m.text())
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// text renders a metric in the Prometheus text format, with its series in order of their label values.
+func (m *metric) text() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# HELP %v %v\n", m.name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(m.help))
+	fmt.Fprintf(&b, "# TYPE %v %v\n", m.name, m.kind)
+
+	var keys []string
+	for k := range m.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		s := m.series[k]
+		if m.kind != "histogram" {
+			fmt.Fprintf(&b, "%v%v %v\n", m.name, labels(m.labels, s.values, "", ""), number(s.value))
+			continue
+		}
+
+		var cumulative uint64
+		for i, bound := range m.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(&b, "%v_bucket%v %v\n", m.name, labels(m.labels, s.values, "le", number(bound)), cumulative)
+		}
+		fmt.Fprintf(&b, "%v_bucket%v %v\n", m.name, labels(m.labels, s.values, "le", "+Inf"), s.count)
+		fmt.Fprintf(&b, "%v_sum%v %v\n", m.name, labels(m.labels, s.values, "", ""), number(s.sum))
+		fmt.Fprintf(&b, "%v_count%v %v\n", m.name, labels(m.labels, s.values, "", ""), s.count)
+	}
+
+	return b.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels formats label names and values as {a="1",b="2"}, with an extra label if extraName is set.
+func labels(names, values []string, extraName, extraValue string) string {
+	var pairs []string
+	for i, name := range names {
+		pairs = append(pairs, fmt.Sprintf(`%v="%v"`, name, labelEscaper.Replace(values[i])))
+	}
+	if extraName != "" {
+		pairs = append(pairs, fmt.Sprintf(`%v="%v"`, extraName, extraValue))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// number formats a sample value.
+func number(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+
+	// Fifteen significant digits hides the rounding error of summing amounts such as 0.1.
+	return strconv.FormatFloat(f, 'g', 15, 64)
+}
+
+// ServeHTTP writes the metrics in response to a scrape.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteTo(w)
+}
@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/stretchr/testify/assert"
+)
+
+func text(r *Registry) string {
+	var buf bytes.Buffer
+	r.WriteTo(&buf)
+	return buf.String()
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	g := r.NewGaugeVec("g", "A gauge.", "name")
+	c := r.NewCounterVec("c", "A counter.")
+	h := r.NewHistogramVec("h", "A histogram.", []float64{1, 0.1}, "path")
+
+	g.Set(1.5, `say "hi"`)
+	g.Set(2, "a")
+	c.Inc()
+	c.Add(0.1)
+	c.Add(0.2)
+	c.Add(-1)
+	h.Observe(0.05, "/a")
+	h.Observe(0.5, "/a")
+	h.Observe(5, "/a")
+
+	assert.Equal(t, `# HELP g A gauge.
+# TYPE g gauge
+g{name="a"} 2
+g{name="say \"hi\""} 1.5
+# HELP c A counter.
+# TYPE c counter
+c 1.3
+# HELP h A histogram.
+# TYPE h histogram
+h_bucket{path="/a",le="0.1"} 1
+h_bucket{path="/a",le="1"} 2
+h_bucket{path="/a",le="+Inf"} 3
+h_sum{path="/a"} 5.55
+h_count{path="/a"} 3
+`, text(r))
+
+	assert.Panics(t, func() { r.NewGaugeVec("g", "Again.") })
+	assert.Panics(t, func() { g.Set(1) })
+}
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/transactions/") {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	r := NewRegistry()
+	client := &http.Client{Transport: NewTransport(r, nil)}
+
+	_, err := client.Get(srv.URL + "/accounts")
+	assert.NoError(t, err)
+	_, err = client.Get(srv.URL + "/transactions/tx_00008zIcpb1TB4yeIFXMzx")
+	assert.NoError(t, err)
+	_, err = client.Get("http://127.0.0.1:0/accounts")
+	assert.Error(t, err)
+
+	out := text(r)
+	assert.Contains(t, out, `mondo_api_requests_total{method="GET",endpoint="/accounts",status="200"} 1`)
+	assert.Contains(t, out, `mondo_api_requests_total{method="GET",endpoint="/transactions/:id",status="404"} 1`)
+	assert.Contains(t, out, `mondo_api_errors_total{method="GET",endpoint="/transactions/:id",status="404"} 1`)
+	assert.Contains(t, out, `mondo_api_errors_total{method="GET",endpoint="/accounts",status="error"} 1`)
+	assert.Contains(t, out, `mondo_api_request_duration_seconds_count{method="GET",endpoint="/accounts"} 2`)
+}
+
+func TestCollector(t *testing.T) {
+	r := NewRegistry()
+	c := NewCollector(r)
+
+	c.SetBalance(mondo.Account{ID: "acc_1", Description: "Peter Pan's Account"}, &mondo.Balance{Balance: 5000, Currency: "GBP", SpendToday: -1200})
+
+	pret := mondo.Transaction{ID: "tx_1", Amount: -350, Currency: "GBP", Category: "eating_out", Merchant: mondo.Merchant{Name: "Pret A Manger"}}
+	c.Observe("acc_1", pret)
+	c.Observe("acc_1", pret)
+	c.Observe("acc_1", mondo.Transaction{ID: "tx_2", Amount: -150, Currency: "GBP", Category: "eating_out", Merchant: mondo.Merchant{Name: "Pret A Manger"}})
+	c.Observe("acc_1", mondo.Transaction{ID: "tx_3", Amount: 10000, Currency: "GBP", Category: "mondo", IsLoad: true})
+	c.Observe("acc_1", mondo.Transaction{ID: "tx_4", Amount: -1000, Currency: "GBP", Category: "cash", Description: "ATM 1 HIGH STREET"})
+	c.Observe("acc_1", mondo.Transaction{ID: "tx_5", Amount: -2000, Currency: "GBP", Category: "cash", Description: "ATM 2 STATION ROAD"})
+
+	out := text(r)
+	assert.Contains(t, out, `mondo_balance{account_id="acc_1",account="Peter Pan's Account",currency="GBP"} 50`)
+	assert.Contains(t, out, `mondo_spend_today{account_id="acc_1",account="Peter Pan's Account",currency="GBP"} 12`)
+	assert.Contains(t, out, `mondo_spend_total{account_id="acc_1",category="eating_out",currency="GBP"} 5`)
+	assert.Contains(t, out, `mondo_merchant_spend_total{account_id="acc_1",merchant="Pret A Manger",currency="GBP"} 5`)
+	assert.Contains(t, out, `mondo_merchant_spend_total{account_id="acc_1",merchant="unknown",currency="GBP"} 30`)
+	assert.NotContains(t, out, "ATM")
+	assert.Contains(t, out, `mondo_transactions_total{account_id="acc_1",category="eating_out"} 2`)
+	assert.Contains(t, out, `mondo_transactions_total{account_id="acc_1",category="mondo"} 1`)
+}
+
+func TestCollectorWindow(t *testing.T) {
+	r := NewRegistry()
+	c := NewCollector(r)
+	c.Window = time.Hour
+	now := time.Date(2015, 8, 22, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	tx := mondo.Transaction{ID: "tx_1", Amount: -350, Currency: "GBP", Category: "eating_out"}
+	c.Observe("acc_1", tx)
+	now = now.Add(59 * time.Minute)
+	c.Observe("acc_1", tx)
+	c.Observe("acc_1", mondo.Transaction{ID: "tx_2", Amount: -150, Currency: "GBP", Category: "eating_out"})
+	assert.Contains(t, text(r), `mondo_transactions_total{account_id="acc_1",category="eating_out"} 2`)
+	assert.Equal(t, 2, len(c.seen))
+
+	// Transactions are forgotten once the window has passed since they were first observed, so memory does not grow without bound.
+	now = now.Add(30 * time.Minute)
+	c.Observe("acc_1", mondo.Transaction{ID: "tx_3", Amount: -100, Currency: "GBP", Category: "groceries"})
+	assert.Equal(t, 2, len(c.seen))
+
+	now = now.Add(2 * time.Hour)
+	c.Observe("acc_1", mondo.Transaction{ID: "tx_4", Amount: -100, Currency: "GBP", Category: "groceries"})
+	assert.Equal(t, 1, len(c.seen))
+}
+
+func TestEndpoint(t *testing.T) {
+	assert.Equal(t, "/transactions/:id", Endpoint("/transactions/tx_00008zIcpb1TB4yeIFXMzx"))
+	assert.Equal(t, "/webhooks/:id", Endpoint("/webhooks/webhook_1"))
+	assert.Equal(t, "/oauth2/token", Endpoint("/oauth2/token"))
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Transport is an http.RoundTripper that measures the latency and outcome of each request, labelled by endpoint. Install it on mondo.HTTPClient to measure calls made to the API:
+//
+//	mondo.HTTPClient = &http.Client{Transport: metrics.NewTransport(registry, nil)}
+type Transport struct {
+	next http.RoundTripper
+
+	latency  *HistogramVec
+	requests *CounterVec
+	errors   *CounterVec
+}
+
+// NewTransport registers API client metrics, and returns a Transport that records them for requests made through next. A nil next uses http.DefaultTransport.
+func NewTransport(r *Registry, next http.RoundTripper) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &Transport{
+		next:     next,
+		latency:  r.NewHistogramVec("mondo_api_request_duration_seconds", "Latency of requests to the Mondo API.", nil, "method", "endpoint"),
+		requests: r.NewCounterVec("mondo_api_requests_total", "Requests made to the Mondo API, by response status.", "method", "endpoint", "status"),
+		errors:   r.NewCounterVec("mondo_api_errors_total", "Requests to the Mondo API that failed, by response status, or \"error\" if no response was received.", "method", "endpoint", "status"),
+	}
+}
+
+// RoundTrip sends a request, and records how long it took and how it went.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+
+	endpoint := Endpoint(req.URL.Path)
+	t.latency.Observe(time.Since(start).Seconds(), req.Method, endpoint)
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	t.requests.Inc(req.Method, endpoint, status)
+	if err != nil || resp.StatusCode >= 400 {
+		t.errors.Inc(req.Method, endpoint, status)
+	}
+
+	return resp, err
+}
+
+// ids matches path segments that are Mondo object IDs, such as tx_00008zIcpb1TB4yeIFXMzx.
+var ids = regexp.MustCompile(`^[a-z]+_[0-9A-Za-z]+$`)
+
+// Endpoint returns the API endpoint a URL path is for, with IDs replaced by :id so that each endpoint is a single label value, such as /transactions/:id.
+func Endpoint(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if ids.MatchString(s) {
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,250 @@
+// Command mondo-exporter exposes the balances and spending of Mondo accounts as Prometheus metrics.
+//
+// It polls the API for balances and new transactions, and can also receive webhook events so that spending shows up as soon as it happens. Metrics about the API calls it makes, such as latency and errors, are exported alongside.
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/sjwhitworth/gomondo/metrics"
+)
+
+// accountFlags collects the repeatable -account flag.
+type accountFlags []string
+
+func (a *accountFlags) String() string {
+	return strings.Join(*a, ",")
+}
+
+func (a *accountFlags) Set(v string) error {
+	*a = append(*a, v)
+	return nil
+}
+
+func main() {
+	// Log to stderr, like the other commands. Requests to the API are logged at debug level.
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel()})))
+	mondo.DefaultLogger = slog.Default()
+
+	if err := run(os.Args[1:]); err != nil {
+		if err != flag.ErrHelp {
+			slog.Error(err.Error())
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	var accounts accountFlags
+	fs := flag.NewFlagSet("mondo-exporter", flag.ContinueOnError)
+	addr := fs.String("addr", ":9292", "address to serve metrics on")
+	metricsPath := fs.String("path", "/metrics", "path to serve metrics on")
+	webhookPath := fs.String("webhook-path", "/webhook", "path to receive webhook events on; empty disables it")
+	webhookToken := fs.String("webhook-token", os.Getenv("MONDO_WEBHOOK_TOKEN"), "token webhook events must carry in their token query parameter; defaults to MONDO_WEBHOOK_TOKEN, and events are not received without one")
+	interval := fs.Duration("interval", time.Minute, "how often to poll the API")
+	since := fs.String("since", "", "count transactions created at or after this RFC3339 time when starting, rather than only new ones")
+	fs.Var(&accounts, "account", "ID of an account to export; repeat for more. Defaults to every account")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *since != "" {
+		if _, err := time.Parse(time.RFC3339, *since); err != nil {
+			return fmt.Errorf("invalid -since %q: use RFC3339", *since)
+		}
+	}
+
+	if u := os.Getenv("MONDO_API_URL"); u != "" {
+		mondo.BaseMondoURL = u
+	}
+
+	registry := metrics.NewRegistry()
+	mondo.HTTPClient = &http.Client{Timeout: 30 * time.Second, Transport: metrics.NewTransport(registry, nil)}
+
+	p := &poller{
+		collector: metrics.NewCollector(registry),
+		accounts:  accounts,
+		since:     *since,
+		token:     *webhookToken,
+		lastIds:   map[string]string{},
+	}
+
+	// Remember transactions for at least a few polls, so that a webhook event arriving late is not counted again.
+	if window := 3 * *interval; window > p.collector.Window {
+		p.collector.Window = window
+	}
+	if err := p.poll(); err != nil {
+		return err
+	}
+
+	go func() {
+		for range time.Tick(*interval) {
+			if err := p.poll(); err != nil {
+				slog.Error("Error polling the API", "error", err)
+			}
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.Handle(*metricsPath, registry)
+	switch {
+	case *webhookPath == "":
+	case *webhookToken == "":
+		slog.Warn("Not receiving webhook events, as no -webhook-token or MONDO_WEBHOOK_TOKEN is set")
+	default:
+		mux.Handle(*webhookPath, p)
+	}
+
+	slog.Info("Serving metrics", "addr", *addr, "path", *metricsPath)
+	return http.ListenAndServe(*addr, mux)
+}
+
+// poller fetches balances and new transactions from the API, and feeds them to a Collector.
+type poller struct {
+	collector *metrics.Collector
+	accounts  []string
+	since     string
+
+	// token must be given by webhook events, so that no one else can inflate the counters.
+	token string
+
+	mu      sync.Mutex
+	client  *mondo.MondoClient
+	lastIds map[string]string
+}
+
+// poll updates the metrics of every exported account. It authenticates again if the access token has expired.
+func (p *poller) poll() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	err := p.pollAccounts()
+	if err == mondo.ErrUnauthenticatedRequest {
+		p.client = nil
+		err = p.pollAccounts()
+	}
+	return err
+}
+
+// pollAccounts does the work of poll. Must be called with p.mu held.
+func (p *poller) pollAccounts() error {
+	if p.client == nil || !p.client.Authenticated() {
+		client, err := mondo.Authenticate(os.Getenv("MONDO_CLIENT_ID"), os.Getenv("MONDO_CLIENT_SECRET"), os.Getenv("MONDO_USERNAME"), os.Getenv("MONDO_PASSWORD"))
+		if err != nil {
+			return err
+		}
+		p.client = client
+	}
+
+	acs, err := p.client.Accounts()
+	if err != nil {
+		return err
+	}
+
+	for _, ac := range acs {
+		if !p.exported(ac.ID) {
+			continue
+		}
+
+		balance, err := p.client.Balance(ac.ID)
+		if err != nil {
+			return err
+		}
+		p.collector.SetBalance(ac, balance)
+
+		if err := p.pollTransactions(ac.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pollTransactions observes the transactions created on an account since it was last polled. Must be called with p.mu held.
+func (p *poller) pollTransactions(accountId string) error {
+	since, ok := p.lastIds[accountId]
+	if !ok {
+		if p.since == "" {
+			// Start from now: only transactions created from here on are counted.
+			since = time.Now().UTC().Format(time.RFC3339)
+		} else {
+			since = p.since
+		}
+	}
+
+	err := mondo.EachPage(p.client, accountId, since, "", 0, func(page []mondo.Transaction) error {
+		for _, tx := range page {
+			p.collector.Observe(accountId, tx)
+		}
+		since = page[len(page)-1].ID
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Until the account has a transaction, keep polling from the time it was first polled.
+	p.lastIds[accountId] = since
+	return nil
+}
+
+func (p *poller) exported(accountId string) bool {
+	if len(p.accounts) == 0 {
+		return true
+	}
+	for _, id := range p.accounts {
+		if id == accountId {
+			return true
+		}
+	}
+	return false
+}
+
+// The largest webhook event accepted, which is far more than a transaction needs.
+const maxEventSize = 64 << 10
+
+// ServeHTTP receives webhook events, so that new transactions are counted without waiting for the next poll. Events must carry the token in their token query parameter, so the URL to register is such as https://example.com/webhook?token=...
+func (p *poller) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(p.token)) != 1 {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	var event mondo.WebhookRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxEventSize)).Decode(&event); err != nil {
+		http.Error(w, "malformed event", http.StatusBadRequest)
+		return
+	}
+
+	if event.Type != mondo.EventTransactionCreated || event.Data == nil || !p.exported(event.Data.AccountID) {
+		return
+	}
+
+	p.collector.Observe(event.Data.AccountID, *event.Data)
+}
+
+// logLevel returns the level set by MONDO_LOG_LEVEL, such as debug or warn, defaulting to info.
+func logLevel() slog.Level {
+	var level slog.Level
+	if v := os.Getenv("MONDO_LOG_LEVEL"); v != "" {
+		if err := level.UnmarshalText([]byte(v)); err != nil {
+			fmt.Fprintf(os.Stderr, "Ignoring MONDO_LOG_LEVEL: %v\n", err)
+		}
+	}
+	return level
+}
@@ -2,12 +2,13 @@
 package mondo
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -15,8 +16,12 @@ var (
 	// The root URL we will base all queries off of. Currently only production is supported.
 	BaseMondoURL = "https://production-api.gmon.io"
 
-	// OAuth grant type.
-	GrantTypePassword = "password"
+	// The HTTP client used for all calls to the Mondo API. Replace it to customise timeouts or the transport, for example to record and replay API interactions.
+	HTTPClient = http.DefaultClient
+
+	// OAuth grant types.
+	GrantTypePassword     = "password"
+	GrantTypeRefreshToken = "refresh_token"
 
 	// 401 response code
 	ErrUnauthenticatedRequest = fmt.Errorf("your request was not sent with a valid token")
@@ -25,10 +30,115 @@ var (
 	ErrNoTransactionFound = fmt.Errorf("no transaction found with ID")
 )
 
+// Client is the set of Mondo API calls made by a MondoClient. Depend on it rather than *MondoClient to swap in a fake, such as mondotest.FakeClient, in tests.
+type Client interface {
+	Accounts() ([]Account, error)
+	Balance(accountId string) (*Balance, error)
+	Transactions(accountId, since, before string, limit int) ([]Transaction, error)
+	TransactionByID(accountId, transactionId string) (*Transaction, error)
+	AnnotateTransaction(transactionId string, metadata map[string]string) (*Transaction, error)
+	CreateFeedItem(accountId, title, imageURL, bgColor, bodyColor, titleColor, body string) error
+	PostFeedItem(accountId string, item *FeedItem) error
+	RegisterWebhook(accountId, URL string) (*Webhook, error)
+	ListWebhooks(accountId string) ([]Webhook, error)
+	DeleteWebhook(webhookId string) error
+	RegisterAttachment(externalId, fileURL, fileType string) (*Attachment, error)
+}
+
+var _ Client = (*MondoClient)(nil)
+
+// MondoClient makes calls to the Mondo API. It is safe for concurrent use.
 type MondoClient struct {
+	// Tracer, if set, traces each call to the API. See Tracer.
+	Tracer Tracer
+
+	// Logger, if set, logs each request to the API. See Logger.
+	Logger Logger
+
+	// ResponseCache, if set, caches responses to reads. See ResponseCache.
+	ResponseCache ResponseCache
+
+	auth        *auth
+	ctx         context.Context
+	bypassCache bool
+}
+
+// auth is the oauth state of a client, shared with the copies WithContext makes. mu guards the token, and refreshMu is held while refreshing it, so that only one refresh runs at a time however many calls are rejected at once.
+type auth struct {
+	clientId, clientSecret string
+
+	mu            sync.Mutex
 	accessToken   string
+	refreshToken  string
 	authenticated bool
 	expiryTime    time.Time
+	userId        string
+
+	// lastWrite is when a call last changed something, such as annotating a transaction. Cached responses stored before it are revalidated before they are used.
+	lastWrite time.Time
+
+	refreshMu sync.Mutex
+}
+
+// set stores a newly issued token.
+func (a *auth) set(tresp *tokenResponse) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.accessToken = tresp.AccessToken
+	a.refreshToken = tresp.RefreshToken
+	a.authenticated = true
+	a.expiryTime = time.Now().Add(time.Duration(tresp.ExpiresIn) * time.Second)
+	if tresp.UserID != "" {
+		a.userId = tresp.UserID
+	}
+}
+
+// user identifies who responses are for, so that cached responses are never shared between users: the user ID, or a hash of the token if the API did not give one.
+func (a *auth) user() string {
+	if a == nil {
+		return ""
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.userId != "" {
+		return a.userId
+	}
+	return hashID(a.accessToken)
+}
+
+// wrote records that a call changed something.
+func (a *auth) wrote() {
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.lastWrite = time.Now()
+}
+
+// written returns when a call last changed something.
+func (a *auth) written() time.Time {
+	if a == nil {
+		return time.Time{}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.lastWrite
+}
+
+// token returns the current access token.
+func (a *auth) token() string {
+	if a == nil {
+		return ""
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.accessToken
 }
 
 // Function Authenticate authenticates the user using the oath flow, returning an authenticated MondoClient
@@ -44,23 +154,41 @@ func Authenticate(clientId, clientSecret, username, password string) (*MondoClie
 	values.Set("username", username)
 	values.Set("password", password)
 
-	resp, err := http.PostForm(buildUrl("oauth2/token"), values)
+	tresp, err := requestToken(DefaultLogger, values)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode == 401 {
-		return nil, ErrUnauthenticatedRequest
+	client := &MondoClient{
+		Logger: DefaultLogger,
+		auth:   &auth{clientId: clientId, clientSecret: clientSecret},
 	}
+	client.auth.set(tresp)
+	return client, nil
+}
 
-	tresp := tokenResponse{}
-	b, err := ioutil.ReadAll(resp.Body)
+// requestToken asks the oauth2/token endpoint for a token.
+func requestToken(logger Logger, values url.Values) (*tokenResponse, error) {
+	req, err := http.NewRequest("POST", buildUrl("oauth2/token"), strings.NewReader(values.Encode()))
 	if err != nil {
 		return nil, err
 	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	start := time.Now()
+	resp, err := HTTPClient.Do(req)
+	logRequest(logger, req, resp, err, time.Since(start), 0)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 401 {
+		return nil, ErrUnauthenticatedRequest
+	}
 
-	if err := json.Unmarshal(b, &tresp); err != nil {
+	tresp := tokenResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&tresp); err != nil {
 		return nil, err
 	}
 
@@ -72,33 +200,126 @@ func Authenticate(clientId, clientSecret, username, password string) (*MondoClie
 		return nil, fmt.Errorf("failed to scan response correctly")
 	}
 
-	return &MondoClient{
-		authenticated: true,
-		accessToken:   tresp.AccessToken,
-		expiryTime:    time.Now().Add(time.Duration(tresp.ExpiresIn) * time.Second),
-	}, nil
+	return &tresp, nil
 }
 
 // ExpiresAt returns the time that the current oauth token expires and will have to be refreshed.
 func (m *MondoClient) ExpiresAt() time.Time {
-	return m.expiryTime
+	if m.auth == nil {
+		return time.Time{}
+	}
+
+	m.auth.mu.Lock()
+	defer m.auth.mu.Unlock()
+	return m.auth.expiryTime
 }
 func (m *MondoClient) Authenticated() bool {
-	if time.Now().Before(m.ExpiresAt()) {
+	if m.auth == nil {
+		return false
+	}
+
+	m.auth.mu.Lock()
+	defer m.auth.mu.Unlock()
+	if time.Now().Before(m.auth.expiryTime) {
 		return true
 	}
-	m.authenticated = false
-	return m.authenticated
+	m.auth.authenticated = false
+	return m.auth.authenticated
 }
 
-// callWithAuth makes authenticated calls to the Mondo API.
-func (m *MondoClient) callWithAuth(methodType, URL string, params map[string]string) (*http.Response, error) {
-	var resp *http.Response
-	var err error
+// refresh exchanges the refresh token for a new access token, after stale was rejected. If another call has already replaced stale, its token is used instead, so concurrent calls rejected together cause a single refresh.
+func (m *MondoClient) refresh(stale string) error {
+	a := m.auth
+	if a == nil {
+		return ErrUnauthenticatedRequest
+	}
+
+	a.refreshMu.Lock()
+	defer a.refreshMu.Unlock()
+
+	a.mu.Lock()
+	current, refreshToken := a.accessToken, a.refreshToken
+	a.mu.Unlock()
+
+	if current != stale {
+		return nil
+	}
 
-	// TODO: This is so hacky, clean up
+	if refreshToken != "" {
+		values := url.Values{}
+		values.Set("grant_type", GrantTypeRefreshToken)
+		values.Set("client_id", a.clientId)
+		values.Set("client_secret", a.clientSecret)
+		values.Set("refresh_token", refreshToken)
+
+		tresp, err := requestToken(m.Logger, values)
+		if err == nil {
+			a.set(tresp)
+			return nil
+		}
+	}
+
+	// Give up on the refresh token, so that calls waiting to refresh it fail straight away.
+	a.mu.Lock()
+	a.authenticated = false
+	a.refreshToken = ""
+	a.mu.Unlock()
+	return ErrUnauthenticatedRequest
+}
+
+// callWithAuth makes authenticated calls to the Mondo API, as part of a call to an endpoint. Reads go through the ResponseCache, if the client has one.
+func (m *MondoClient) callWithAuth(c *call, methodType, URL string, params map[string]string) (*http.Response, error) {
+	if m.ResponseCache == nil {
+		return m.send(c, methodType, URL, params, nil)
+	}
+
+	if methodType == "GET" {
+		return m.cachedGet(c, URL, params)
+	}
+
+	resp, err := m.send(c, methodType, URL, params, nil)
+	m.auth.wrote()
+	return resp, err
+}
+
+// send makes an authenticated request to the Mondo API, with any extra headers given. If the access token is rejected, it is refreshed and the request retried once.
+func (m *MondoClient) send(c *call, methodType, URL string, params map[string]string, header http.Header) (*http.Response, error) {
+	for {
+		req, err := newRequest(methodType, URL, params)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+
+		token := m.auth.token()
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %v", token))
+
+		start := time.Now()
+		resp, err := HTTPClient.Do(c.prepare(req))
+		logRequest(m.Logger, req, resp, err, time.Since(start), c.retries)
+		if err != nil {
+			return nil, err
+		}
+		c.setAttribute(AttributeStatusCode, resp.StatusCode)
+
+		if resp.StatusCode != 401 {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		if c.retries > 0 || m.refresh(token) != nil {
+			return nil, ErrUnauthenticatedRequest
+		}
+		c.retries++
+	}
+}
+
+// newRequest builds a request to the Mondo API, with params in the query string or the form body depending on the method.
+func newRequest(methodType, URL string, params map[string]string) (*http.Request, error) {
 	switch methodType {
-	case "GET":
+	case "GET", "DELETE":
 		req, err := http.NewRequest(methodType, buildUrl(URL), nil)
 		if err != nil {
 			return nil, err
@@ -112,19 +333,9 @@ func (m *MondoClient) callWithAuth(methodType, URL string, params map[string]str
 			}
 			req.URL.RawQuery = query.Encode()
 		}
+		return req, nil
 
-		req.Header.Add("Authorization", fmt.Sprintf("Bearer %v", m.accessToken))
-		resp, err = http.DefaultClient.Do(req)
-		if err != nil {
-			return nil, err
-		}
-
-		if resp.StatusCode == 401 {
-			m.authenticated = false
-			return nil, ErrUnauthenticatedRequest
-		}
-
-	case "POST":
+	case "POST", "PATCH":
 		form := url.Values{}
 		for k, v := range params {
 			form.Set(k, v)
@@ -134,29 +345,71 @@ func (m *MondoClient) callWithAuth(methodType, URL string, params map[string]str
 		if err != nil {
 			return nil, err
 		}
-
 		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-		req.Header.Add("Authorization", fmt.Sprintf("Bearer %v", m.accessToken))
-		resp, err = http.DefaultClient.Do(req)
+		return req, nil
+	}
+
+	return nil, fmt.Errorf("unsupported method %v", methodType)
+}
+
+// Transactions returns a slice of Transactions, with the merchant expanded within the Transaction. This endpoint supports pagination. To paginate, provide the last Transacation.ID to the since parameter of the function, if the length of the results that are returned is equal to your limit.
+func (m *MondoClient) Transactions(accountId, since, before string, limit int) (transactions []Transaction, err error) {
+	c := m.startCall("Transactions")
+	defer func() { c.end(err) }()
+
+	err = m.eachTransaction(c, accountId, since, before, limit, func(tx Transaction) error {
+		transactions = append(transactions, tx)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// EachTransaction fetches the same page of transactions as Transactions, but calls fn with each one as it is decoded from the response rather than holding the whole page in memory. If fn returns an error, the rest of the page is discarded and the error returned.
+func (m *MondoClient) EachTransaction(accountId, since, before string, limit int, fn func(Transaction) error) (err error) {
+	c := m.startCall("EachTransaction")
+	defer func() { c.end(err) }()
+
+	return m.eachTransaction(c, accountId, since, before, limit, fn)
+}
+
+// MaxPageSize is the largest page of transactions the API returns.
+const MaxPageSize = 100
+
+// EachPage pages through the transactions of an account, calling fn with each page in turn. since and before are as for Transactions, and either may be empty. At most limit transactions are fetched, unless limit is 0. If fn returns an error, paging stops and the error is returned.
+func EachPage(client Client, accountId, since, before string, limit int, fn func([]Transaction) error) error {
+	fetched := 0
+	for {
+		pageSize := MaxPageSize
+		if limit > 0 && limit-fetched < pageSize {
+			pageSize = limit - fetched
+		}
+
+		page, err := client.Transactions(accountId, since, before, pageSize)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		if resp.StatusCode == 401 {
-			m.authenticated = false
-			return nil, ErrUnauthenticatedRequest
+		if len(page) > 0 {
+			if err := fn(page); err != nil {
+				return err
+			}
 		}
-	}
+		fetched += len(page)
 
-	return resp, err
-}
+		if len(page) < pageSize || (limit > 0 && fetched >= limit) {
+			return nil
+		}
 
-// Transactions returns a slice of Transactions, with the merchant expanded within the Transaction. This endpoint supports pagination. To paginate, provide the last Transacation.ID to the since parameter of the function, if the length of the results that are returned is equal to your limit.
-func (m *MondoClient) Transactions(accountId, since, before string, limit int) ([]Transaction, error) {
-	type transactionsResponse struct {
-		Transactions []Transaction `json:"transactions"`
+		// Paginate by passing the last transaction ID we saw as since.
+		since = page[len(page)-1].ID
 	}
+}
 
+func (m *MondoClient) eachTransaction(c *call, accountId, since, before string, limit int, fn func(Transaction) error) error {
 	params := map[string]string{
 		"account_id": accountId,
 		"expand[]":   "merchant",
@@ -165,23 +418,20 @@ func (m *MondoClient) Transactions(accountId, since, before string, limit int) (
 		"before":     before,
 	}
 
-	resp, err := m.callWithAuth("GET", "transactions", params)
+	c.setAccount(accountId)
+	c.setAttribute(AttributePageSize, limit)
+
+	resp, err := m.callWithAuth(c, "GET", "transactions", params)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer resp.Body.Close()
 
-	tresp := transactionsResponse{}
-	b, err := ioutil.ReadAll(resp.Body)
-	if err := json.Unmarshal(b, &tresp); err != nil {
-		return nil, err
-	}
-
-	return tresp.Transactions, nil
+	return decodeTransactions(resp.Body, fn)
 }
 
 // TransactionByID obtains a Mondo Transaction by a specific transaction ID.
-func (m *MondoClient) TransactionByID(accountId, transactionId string) (*Transaction, error) {
+func (m *MondoClient) TransactionByID(accountId, transactionId string) (transaction *Transaction, err error) {
 	type transactionByIDResponse struct {
 		Transaction Transaction `json:"transaction"`
 	}
@@ -191,7 +441,11 @@ func (m *MondoClient) TransactionByID(accountId, transactionId string) (*Transac
 		"expand[]":   "merchant",
 	}
 
-	resp, err := m.callWithAuth("GET", fmt.Sprintf("transactions/%s", transactionId), params)
+	c := m.startCall("TransactionByID")
+	defer func() { c.end(err) }()
+	c.setAccount(accountId)
+
+	resp, err := m.callWithAuth(c, "GET", fmt.Sprintf("transactions/%s", transactionId), params)
 	if err != nil {
 		return nil, err
 	}
@@ -202,42 +456,114 @@ func (m *MondoClient) TransactionByID(accountId, transactionId string) (*Transac
 	}
 
 	tresp := transactionByIDResponse{}
-	b, err := ioutil.ReadAll(resp.Body)
-	if err := json.Unmarshal(b, &tresp); err != nil {
+	if err := json.NewDecoder(resp.Body).Decode(&tresp); err != nil {
 		return nil, err
 	}
 
 	return &tresp.Transaction, nil
 }
 
-func (m *MondoClient) Accounts() ([]Account, error) {
+// AnnotateTransaction stores key-value metadata against a transaction, and returns the updated transaction. Setting a key to the empty string deletes it.
+func (m *MondoClient) AnnotateTransaction(transactionId string, metadata map[string]string) (transaction *Transaction, err error) {
+	type annotateTransactionResponse struct {
+		Transaction Transaction `json:"transaction"`
+	}
+
+	if transactionId == "" {
+		return nil, fmt.Errorf("transactionId cannot be empty")
+	}
+
+	if len(metadata) == 0 {
+		return nil, fmt.Errorf("metadata cannot be empty")
+	}
+
+	params := map[string]string{}
+	for k, v := range metadata {
+		params[fmt.Sprintf("metadata[%s]", k)] = v
+	}
+
+	c := m.startCall("AnnotateTransaction")
+	defer func() { c.end(err) }()
+
+	resp, err := m.callWithAuth(c, "PATCH", fmt.Sprintf("transactions/%s", transactionId), params)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return nil, ErrNoTransactionFound
+	}
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to annotate transaction %v: %v", transactionId, resp.Status)
+	}
+
+	tresp := annotateTransactionResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&tresp); err != nil {
+		return nil, err
+	}
+
+	return &tresp.Transaction, nil
+}
+
+func (m *MondoClient) Accounts() (accounts []Account, err error) {
 	type accountsResponse struct {
 		Accounts []Account `json:"accounts"`
 	}
 
-	resp, err := m.callWithAuth("GET", "accounts", nil)
+	c := m.startCall("Accounts")
+	defer func() { c.end(err) }()
+
+	resp, err := m.callWithAuth(c, "GET", "accounts", nil)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	acresp := accountsResponse{}
-	b, err := ioutil.ReadAll(resp.Body)
-	if err := json.Unmarshal(b, &acresp); err != nil {
+	if err := json.NewDecoder(resp.Body).Decode(&acresp); err != nil {
 		return nil, err
 	}
 
 	return acresp.Accounts, nil
 }
 
-// CreateFeedItem creates a feed item in the user's application.
-// TODO: There is no way to delete a feed item currently, so use with caution.
-func (m *MondoClient) CreateFeedItem(accountId, title, imageURL, bgColor, bodyColor, titleColor, body string) error {
-	type feedItemResponse struct {
-		Code    string `json:"code"`
-		Message string `json:"message"`
+// Balance returns the balance of an account, and the amount spent from it today.
+func (m *MondoClient) Balance(accountId string) (balance *Balance, err error) {
+	if accountId == "" {
+		return nil, fmt.Errorf("accountId cannot be empty")
+	}
+
+	params := map[string]string{
+		"account_id": accountId,
 	}
 
+	c := m.startCall("Balance")
+	defer func() { c.end(err) }()
+	c.setAccount(accountId)
+
+	resp, err := m.callWithAuth(c, "GET", "balance", params)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to get balance for account %v: %v", accountId, resp.Status)
+	}
+
+	var bresp Balance
+	if err := json.NewDecoder(resp.Body).Decode(&bresp); err != nil {
+		return nil, err
+	}
+
+	return &bresp, nil
+}
+
+// CreateFeedItem creates a basic feed item in the user's application. It is kept for compatibility; PostFeedItem supports every param.
+// TODO: There is no way to delete a feed item currently, so use with caution.
+func (m *MondoClient) CreateFeedItem(accountId, title, imageURL, bgColor, bodyColor, titleColor, body string) error {
 	if imageURL == "" {
 		return fmt.Errorf("imageURL cannot be empty")
 	}
@@ -262,39 +588,12 @@ func (m *MondoClient) CreateFeedItem(accountId, title, imageURL, bgColor, bodyCo
 		titleColor = "#333"
 	}
 
-	params := map[string]string{
-		"account_id":               accountId,
-		"type":                     "basic",
-		"params[title]":            title,
-		"params[image_url]":        imageURL,
-		"params[background_color]": bgColor,
-		"params[body_color]":       bodyColor,
-		"params[title_color]":      titleColor,
-		"params[body]":             body,
-	}
-
-	resp, err := m.callWithAuth("POST", "feed", params)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	var fresp feedItemResponse
-	b, err := ioutil.ReadAll(resp.Body)
-	if err := json.Unmarshal(b, &fresp); err != nil {
-		return err
-	}
-
-	// Generate a nicely formatted error code back to the caller
-	if fresp.Code != "" {
-		return fmt.Errorf("%v: %v", fresp.Code, fresp.Message)
-	}
-
-	return nil
+	item := NewFeedItem(title).WithBody(body).WithImage(imageURL).WithColors(bgColor, bodyColor, titleColor)
+	return m.PostFeedItem(accountId, item)
 }
 
 // Registers a web hook. Each time a matching event occurs, we will make a POST call to the URL you provide. If the call fails, we will retry up to a maximum of 5 attempts, with exponential backoff.
-func (m *MondoClient) RegisterWebhook(accountId, URL string) (*Webhook, error) {
+func (m *MondoClient) RegisterWebhook(accountId, URL string) (webhook *Webhook, err error) {
 	type registerWebhookResponse struct {
 		Webhook Webhook `json:"webhook"`
 	}
@@ -312,33 +611,84 @@ func (m *MondoClient) RegisterWebhook(accountId, URL string) (*Webhook, error) {
 		"url":        URL,
 	}
 
-	resp, err := m.callWithAuth("POST", "webhooks", params)
+	c := m.startCall("RegisterWebhook")
+	defer func() { c.end(err) }()
+	c.setAccount(accountId)
+
+	resp, err := m.callWithAuth(c, "POST", "webhooks", params)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	var wresp registerWebhookResponse
-	b, err := ioutil.ReadAll(resp.Body)
-	if err := json.Unmarshal(b, &wresp); err != nil {
+	if err := json.NewDecoder(resp.Body).Decode(&wresp); err != nil {
 		return nil, err
 	}
 
 	return &wresp.Webhook, nil
 }
 
+// ListWebhooks returns the web hooks registered against an account.
+func (m *MondoClient) ListWebhooks(accountId string) (webhooks []Webhook, err error) {
+	type listWebhooksResponse struct {
+		Webhooks []Webhook `json:"webhooks"`
+	}
+
+	if accountId == "" {
+		return nil, fmt.Errorf("accountId cannot be empty")
+	}
+
+	params := map[string]string{
+		"account_id": accountId,
+	}
+
+	c := m.startCall("ListWebhooks")
+	defer func() { c.end(err) }()
+	c.setAccount(accountId)
+
+	resp, err := m.callWithAuth(c, "GET", "webhooks", params)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to list webhooks for account %v: %v", accountId, resp.Status)
+	}
+
+	var wresp listWebhooksResponse
+	if err := json.NewDecoder(resp.Body).Decode(&wresp); err != nil {
+		return nil, err
+	}
+
+	return wresp.Webhooks, nil
+}
+
 // Deletes a web hook. When you delete a web hook, we will no longer send notifications to it.
-func (m *MondoClient) DeleteWebhook(webhookId string) error {
+func (m *MondoClient) DeleteWebhook(webhookId string) (err error) {
 	if webhookId == "" {
 		return fmt.Errorf("webhookId cannot be empty")
 	}
 
-	_, err := m.callWithAuth("DELETE", fmt.Sprintf("webhooks/%s", webhookId), nil)
-	return err
+	c := m.startCall("DeleteWebhook")
+	defer func() { c.end(err) }()
+
+	resp, err := m.callWithAuth(c, "DELETE", fmt.Sprintf("webhooks/%s", webhookId), nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to delete webhook %v: %v", webhookId, resp.Status)
+	}
+
+	return nil
 }
 
 // Registers an attachment. Once you have obtained a URL for an attachment, either by uploading to the upload_url obtained from the upload endpoint above or by hosting a remote image, this URL can then be registered against a transaction. Once an attachment is registered against a transaction this will be displayed on the detail page of a transaction within the Mondo app.
-func (m *MondoClient) RegisterAttachment(externalId, fileURL, fileType string) (*Attachment, error) {
+func (m *MondoClient) RegisterAttachment(externalId, fileURL, fileType string) (attachment *Attachment, err error) {
 	type registerAttachmentResponse struct {
 		Attachment Attachment `json:"attachment"`
 	}
@@ -361,15 +711,17 @@ func (m *MondoClient) RegisterAttachment(externalId, fileURL, fileType string) (
 		"file_url":    fileURL,
 	}
 
-	resp, err := m.callWithAuth("POST", "attachment/register", params)
+	c := m.startCall("RegisterAttachment")
+	defer func() { c.end(err) }()
+
+	resp, err := m.callWithAuth(c, "POST", "attachment/register", params)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	var aresp registerAttachmentResponse
-	b, err := ioutil.ReadAll(resp.Body)
-	if err := json.Unmarshal(b, &aresp); err != nil {
+	if err := json.NewDecoder(resp.Body).Decode(&aresp); err != nil {
 		return nil, err
 	}
 
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -55,7 +56,7 @@ func TestOAuth(t *testing.T) {
 
 	assert.NotNil(t, client)
 	assert.False(t, client.ExpiresAt().Before(time.Now()))
-	assert.True(t, client.authenticated)
+	assert.True(t, client.auth.authenticated)
 
 	client, err = Authenticate("some", "notvalid", "credentials", "here")
 	assert.Error(t, err)
@@ -149,6 +150,62 @@ func TestTransactions(t *testing.T) {
 	assert.Equal(t, transactions[0].Merchant.Emoji, "🍞")
 }
 
+func TestEachPage(t *testing.T) {
+	setup()
+	defer teardown()
+
+	// 150 transactions, paged through by ID as the API does.
+	mux.HandleFunc("/transactions",
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "2015-08-01T00:00:00Z", r.FormValue("before"))
+			start := 0
+			if since := r.FormValue("since"); since != "2015-07-01T00:00:00Z" {
+				fmt.Sscanf(since, "tx_%d", &start)
+				start++
+			}
+			var limit int
+			fmt.Sscanf(r.FormValue("limit"), "%d", &limit)
+
+			var txs []string
+			for i := start; i < 150 && len(txs) < limit; i++ {
+				txs = append(txs, fmt.Sprintf(`{"id": "tx_%03d"}`, i))
+			}
+			fmt.Fprintf(w, `{"transactions": [%v]}`, strings.Join(txs, ","))
+		},
+	)
+
+	client, err := Authenticate("some", "valid", "credentials", "here")
+	assert.NoError(t, err)
+
+	var sizes []int
+	err = EachPage(client, "an account", "2015-07-01T00:00:00Z", "2015-08-01T00:00:00Z", 0, func(page []Transaction) error {
+		sizes = append(sizes, len(page))
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{MaxPageSize, 50}, sizes)
+
+	// Paging stops at the limit, or when fn fails.
+	var ids []string
+	err = EachPage(client, "an account", "2015-07-01T00:00:00Z", "2015-08-01T00:00:00Z", 3, func(page []Transaction) error {
+		for _, tx := range page {
+			ids = append(ids, tx.ID)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tx_000", "tx_001", "tx_002"}, ids)
+
+	stop := fmt.Errorf("stop")
+	calls := 0
+	err = EachPage(client, "an account", "2015-07-01T00:00:00Z", "2015-08-01T00:00:00Z", 0, func(page []Transaction) error {
+		calls++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, calls)
+}
+
 func TestAuthentication(t *testing.T) {
 	setup()
 	defer teardown()
@@ -157,7 +214,7 @@ func TestAuthentication(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, client.Authenticated())
 
-	client.expiryTime = time.Now()
+	client.auth.expiryTime = time.Now()
 	assert.False(t, client.Authenticated())
 }
 
@@ -243,6 +300,42 @@ func TestAccounts(t *testing.T) {
 	assert.Equal(t, "2015-11-13T12:17:42Z", account.Created.Format(time.RFC3339))
 }
 
+func TestBalance(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/balance",
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "GET", r.Method)
+			if r.FormValue("account_id") == "someone_else" {
+				w.WriteHeader(403)
+				fmt.Fprint(w, `{"code": "forbidden.insufficient_permissions"}`)
+				return
+			}
+			assert.Equal(t, "account1", r.FormValue("account_id"))
+			fmt.Fprint(w, `{
+									    "balance": 5000,
+									    "currency": "GBP",
+									    "spend_today": -1200
+									}`)
+		},
+	)
+
+	client, err := Authenticate("some", "valid", "credentials", "here")
+	assert.NoError(t, err)
+
+	balance, err := client.Balance("account1")
+	assert.NoError(t, err)
+	assert.Equal(t, 5000, balance.Balance)
+	assert.Equal(t, "GBP", balance.Currency)
+	assert.Equal(t, -1200, balance.SpendToday)
+
+	// An error is not mistaken for an empty balance.
+	balance, err = client.Balance("someone_else")
+	assert.Error(t, err)
+	assert.Nil(t, balance)
+}
+
 func TestCreateItem(t *testing.T) {
 	setup()
 	defer teardown()
@@ -294,3 +387,85 @@ func TestRegisterWebhook(t *testing.T) {
 
 	assert.Equal(t, "http://www.google.com", webhook.Url)
 }
+
+func TestListAndDeleteWebhooks(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/webhooks",
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "GET", r.Method)
+			if r.FormValue("account_id") == "someone_else" {
+				w.WriteHeader(403)
+				fmt.Fprint(w, `{"code": "forbidden.insufficient_permissions"}`)
+				return
+			}
+			assert.Equal(t, "account1", r.FormValue("account_id"))
+			fmt.Fprint(w, `{
+									    "webhooks": [
+									        {
+									            "account_id": "account1",
+									            "id": "webhook_id",
+									            "url": "http://www.google.com"
+									        }
+									    ]
+									}`)
+		},
+	)
+
+	deleted := false
+	mux.HandleFunc("/webhooks/webhook_id",
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "DELETE", r.Method)
+			deleted = true
+			fmt.Fprint(w, `{}`)
+		},
+	)
+
+	client, err := Authenticate("some", "valid", "credentials", "here")
+	assert.NoError(t, err)
+
+	webhooks, err := client.ListWebhooks("account1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(webhooks))
+	assert.Equal(t, "webhook_id", webhooks[0].Id)
+
+	webhooks, err = client.ListWebhooks("someone_else")
+	assert.Error(t, err)
+	assert.Empty(t, webhooks)
+
+	assert.NoError(t, client.DeleteWebhook("webhook_id"))
+	assert.True(t, deleted)
+
+	assert.Error(t, client.DeleteWebhook("missing"))
+}
+
+func TestAnnotateTransaction(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/transactions/tx_1",
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "PATCH", r.Method)
+			assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+			r.ParseForm()
+			assert.Equal(t, "weekly shop", r.PostForm.Get("metadata[notes]"))
+			fmt.Fprint(w, `{
+									    "transaction": {
+									        "id": "tx_1",
+									        "metadata": {"notes": "weekly shop"}
+									    }
+									}`)
+		},
+	)
+
+	client, err := Authenticate("some", "valid", "credentials", "here")
+	assert.NoError(t, err)
+
+	tx, err := client.AnnotateTransaction("tx_1", map[string]string{"notes": "weekly shop"})
+	assert.NoError(t, err)
+	assert.Equal(t, "weekly shop", tx.Metadata["notes"])
+
+	_, err = client.AnnotateTransaction("tx_1", nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,891 @@
+package mondotest
+
+import (
+	"sync"
+
+	"github.com/sjwhitworth/gomondo"
+)
+
+// FakeClient is an in-memory implementation of mondo.Client. For each method Foo it records the arguments of every call, which can be read back with FooCallCount and FooArgsForCall, and returns values programmed with FooReturns, FooReturnsOnCall or FooCalls. Methods that have not been programmed return zero values. It is safe for concurrent use.
+type FakeClient struct {
+	AccountsStub        func() ([]mondo.Account, error)
+	accountsMutex       sync.RWMutex
+	accountsArgsForCall []struct {
+	}
+	accountsReturns struct {
+		result1 []mondo.Account
+		result2 error
+	}
+	accountsReturnsOnCall map[int]struct {
+		result1 []mondo.Account
+		result2 error
+	}
+	BalanceStub        func(string) (*mondo.Balance, error)
+	balanceMutex       sync.RWMutex
+	balanceArgsForCall []struct {
+		arg1 string
+	}
+	balanceReturns struct {
+		result1 *mondo.Balance
+		result2 error
+	}
+	balanceReturnsOnCall map[int]struct {
+		result1 *mondo.Balance
+		result2 error
+	}
+	TransactionsStub        func(string, string, string, int) ([]mondo.Transaction, error)
+	transactionsMutex       sync.RWMutex
+	transactionsArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 int
+	}
+	transactionsReturns struct {
+		result1 []mondo.Transaction
+		result2 error
+	}
+	transactionsReturnsOnCall map[int]struct {
+		result1 []mondo.Transaction
+		result2 error
+	}
+	TransactionByIDStub        func(string, string) (*mondo.Transaction, error)
+	transactionByIDMutex       sync.RWMutex
+	transactionByIDArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	transactionByIDReturns struct {
+		result1 *mondo.Transaction
+		result2 error
+	}
+	transactionByIDReturnsOnCall map[int]struct {
+		result1 *mondo.Transaction
+		result2 error
+	}
+	AnnotateTransactionStub        func(string, map[string]string) (*mondo.Transaction, error)
+	annotateTransactionMutex       sync.RWMutex
+	annotateTransactionArgsForCall []struct {
+		arg1 string
+		arg2 map[string]string
+	}
+	annotateTransactionReturns struct {
+		result1 *mondo.Transaction
+		result2 error
+	}
+	annotateTransactionReturnsOnCall map[int]struct {
+		result1 *mondo.Transaction
+		result2 error
+	}
+	CreateFeedItemStub        func(string, string, string, string, string, string, string) error
+	createFeedItemMutex       sync.RWMutex
+	createFeedItemArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 string
+		arg6 string
+		arg7 string
+	}
+	createFeedItemReturns struct {
+		result1 error
+	}
+	createFeedItemReturnsOnCall map[int]struct {
+		result1 error
+	}
+	PostFeedItemStub        func(string, *mondo.FeedItem) error
+	postFeedItemMutex       sync.RWMutex
+	postFeedItemArgsForCall []struct {
+		arg1 string
+		arg2 *mondo.FeedItem
+	}
+	postFeedItemReturns struct {
+		result1 error
+	}
+	postFeedItemReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RegisterWebhookStub        func(string, string) (*mondo.Webhook, error)
+	registerWebhookMutex       sync.RWMutex
+	registerWebhookArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	registerWebhookReturns struct {
+		result1 *mondo.Webhook
+		result2 error
+	}
+	registerWebhookReturnsOnCall map[int]struct {
+		result1 *mondo.Webhook
+		result2 error
+	}
+	ListWebhooksStub        func(string) ([]mondo.Webhook, error)
+	listWebhooksMutex       sync.RWMutex
+	listWebhooksArgsForCall []struct {
+		arg1 string
+	}
+	listWebhooksReturns struct {
+		result1 []mondo.Webhook
+		result2 error
+	}
+	listWebhooksReturnsOnCall map[int]struct {
+		result1 []mondo.Webhook
+		result2 error
+	}
+	DeleteWebhookStub        func(string) error
+	deleteWebhookMutex       sync.RWMutex
+	deleteWebhookArgsForCall []struct {
+		arg1 string
+	}
+	deleteWebhookReturns struct {
+		result1 error
+	}
+	deleteWebhookReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RegisterAttachmentStub        func(string, string, string) (*mondo.Attachment, error)
+	registerAttachmentMutex       sync.RWMutex
+	registerAttachmentArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}
+	registerAttachmentReturns struct {
+		result1 *mondo.Attachment
+		result2 error
+	}
+	registerAttachmentReturnsOnCall map[int]struct {
+		result1 *mondo.Attachment
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+var _ mondo.Client = (*FakeClient)(nil)
+
+func (fake *FakeClient) Accounts() ([]mondo.Account, error) {
+	fake.accountsMutex.Lock()
+	ret, specificReturn := fake.accountsReturnsOnCall[len(fake.accountsArgsForCall)]
+	fake.accountsArgsForCall = append(fake.accountsArgsForCall, struct {
+	}{})
+	stub := fake.AccountsStub
+	fakeReturns := fake.accountsReturns
+	fake.recordInvocation("Accounts", []interface{}{})
+	fake.accountsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) AccountsCallCount() int {
+	fake.accountsMutex.RLock()
+	defer fake.accountsMutex.RUnlock()
+	return len(fake.accountsArgsForCall)
+}
+
+func (fake *FakeClient) AccountsCalls(stub func() ([]mondo.Account, error)) {
+	fake.accountsMutex.Lock()
+	defer fake.accountsMutex.Unlock()
+	fake.AccountsStub = stub
+}
+
+func (fake *FakeClient) AccountsReturns(result1 []mondo.Account, result2 error) {
+	fake.accountsMutex.Lock()
+	defer fake.accountsMutex.Unlock()
+	fake.AccountsStub = nil
+	fake.accountsReturns = struct {
+		result1 []mondo.Account
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) AccountsReturnsOnCall(i int, result1 []mondo.Account, result2 error) {
+	fake.accountsMutex.Lock()
+	defer fake.accountsMutex.Unlock()
+	fake.AccountsStub = nil
+	if fake.accountsReturnsOnCall == nil {
+		fake.accountsReturnsOnCall = make(map[int]struct {
+			result1 []mondo.Account
+			result2 error
+		})
+	}
+	fake.accountsReturnsOnCall[i] = struct {
+		result1 []mondo.Account
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) Balance(arg1 string) (*mondo.Balance, error) {
+	fake.balanceMutex.Lock()
+	ret, specificReturn := fake.balanceReturnsOnCall[len(fake.balanceArgsForCall)]
+	fake.balanceArgsForCall = append(fake.balanceArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.BalanceStub
+	fakeReturns := fake.balanceReturns
+	fake.recordInvocation("Balance", []interface{}{arg1})
+	fake.balanceMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) BalanceCallCount() int {
+	fake.balanceMutex.RLock()
+	defer fake.balanceMutex.RUnlock()
+	return len(fake.balanceArgsForCall)
+}
+
+func (fake *FakeClient) BalanceCalls(stub func(string) (*mondo.Balance, error)) {
+	fake.balanceMutex.Lock()
+	defer fake.balanceMutex.Unlock()
+	fake.BalanceStub = stub
+}
+
+func (fake *FakeClient) BalanceArgsForCall(i int) string {
+	fake.balanceMutex.RLock()
+	defer fake.balanceMutex.RUnlock()
+	argsForCall := fake.balanceArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeClient) BalanceReturns(result1 *mondo.Balance, result2 error) {
+	fake.balanceMutex.Lock()
+	defer fake.balanceMutex.Unlock()
+	fake.BalanceStub = nil
+	fake.balanceReturns = struct {
+		result1 *mondo.Balance
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) BalanceReturnsOnCall(i int, result1 *mondo.Balance, result2 error) {
+	fake.balanceMutex.Lock()
+	defer fake.balanceMutex.Unlock()
+	fake.BalanceStub = nil
+	if fake.balanceReturnsOnCall == nil {
+		fake.balanceReturnsOnCall = make(map[int]struct {
+			result1 *mondo.Balance
+			result2 error
+		})
+	}
+	fake.balanceReturnsOnCall[i] = struct {
+		result1 *mondo.Balance
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) Transactions(arg1 string, arg2 string, arg3 string, arg4 int) ([]mondo.Transaction, error) {
+	fake.transactionsMutex.Lock()
+	ret, specificReturn := fake.transactionsReturnsOnCall[len(fake.transactionsArgsForCall)]
+	fake.transactionsArgsForCall = append(fake.transactionsArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 int
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.TransactionsStub
+	fakeReturns := fake.transactionsReturns
+	fake.recordInvocation("Transactions", []interface{}{arg1, arg2, arg3, arg4})
+	fake.transactionsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) TransactionsCallCount() int {
+	fake.transactionsMutex.RLock()
+	defer fake.transactionsMutex.RUnlock()
+	return len(fake.transactionsArgsForCall)
+}
+
+func (fake *FakeClient) TransactionsCalls(stub func(string, string, string, int) ([]mondo.Transaction, error)) {
+	fake.transactionsMutex.Lock()
+	defer fake.transactionsMutex.Unlock()
+	fake.TransactionsStub = stub
+}
+
+func (fake *FakeClient) TransactionsArgsForCall(i int) (string, string, string, int) {
+	fake.transactionsMutex.RLock()
+	defer fake.transactionsMutex.RUnlock()
+	argsForCall := fake.transactionsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeClient) TransactionsReturns(result1 []mondo.Transaction, result2 error) {
+	fake.transactionsMutex.Lock()
+	defer fake.transactionsMutex.Unlock()
+	fake.TransactionsStub = nil
+	fake.transactionsReturns = struct {
+		result1 []mondo.Transaction
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) TransactionsReturnsOnCall(i int, result1 []mondo.Transaction, result2 error) {
+	fake.transactionsMutex.Lock()
+	defer fake.transactionsMutex.Unlock()
+	fake.TransactionsStub = nil
+	if fake.transactionsReturnsOnCall == nil {
+		fake.transactionsReturnsOnCall = make(map[int]struct {
+			result1 []mondo.Transaction
+			result2 error
+		})
+	}
+	fake.transactionsReturnsOnCall[i] = struct {
+		result1 []mondo.Transaction
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) TransactionByID(arg1 string, arg2 string) (*mondo.Transaction, error) {
+	fake.transactionByIDMutex.Lock()
+	ret, specificReturn := fake.transactionByIDReturnsOnCall[len(fake.transactionByIDArgsForCall)]
+	fake.transactionByIDArgsForCall = append(fake.transactionByIDArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.TransactionByIDStub
+	fakeReturns := fake.transactionByIDReturns
+	fake.recordInvocation("TransactionByID", []interface{}{arg1, arg2})
+	fake.transactionByIDMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) TransactionByIDCallCount() int {
+	fake.transactionByIDMutex.RLock()
+	defer fake.transactionByIDMutex.RUnlock()
+	return len(fake.transactionByIDArgsForCall)
+}
+
+func (fake *FakeClient) TransactionByIDCalls(stub func(string, string) (*mondo.Transaction, error)) {
+	fake.transactionByIDMutex.Lock()
+	defer fake.transactionByIDMutex.Unlock()
+	fake.TransactionByIDStub = stub
+}
+
+func (fake *FakeClient) TransactionByIDArgsForCall(i int) (string, string) {
+	fake.transactionByIDMutex.RLock()
+	defer fake.transactionByIDMutex.RUnlock()
+	argsForCall := fake.transactionByIDArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeClient) TransactionByIDReturns(result1 *mondo.Transaction, result2 error) {
+	fake.transactionByIDMutex.Lock()
+	defer fake.transactionByIDMutex.Unlock()
+	fake.TransactionByIDStub = nil
+	fake.transactionByIDReturns = struct {
+		result1 *mondo.Transaction
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) TransactionByIDReturnsOnCall(i int, result1 *mondo.Transaction, result2 error) {
+	fake.transactionByIDMutex.Lock()
+	defer fake.transactionByIDMutex.Unlock()
+	fake.TransactionByIDStub = nil
+	if fake.transactionByIDReturnsOnCall == nil {
+		fake.transactionByIDReturnsOnCall = make(map[int]struct {
+			result1 *mondo.Transaction
+			result2 error
+		})
+	}
+	fake.transactionByIDReturnsOnCall[i] = struct {
+		result1 *mondo.Transaction
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) AnnotateTransaction(arg1 string, arg2 map[string]string) (*mondo.Transaction, error) {
+	fake.annotateTransactionMutex.Lock()
+	ret, specificReturn := fake.annotateTransactionReturnsOnCall[len(fake.annotateTransactionArgsForCall)]
+	fake.annotateTransactionArgsForCall = append(fake.annotateTransactionArgsForCall, struct {
+		arg1 string
+		arg2 map[string]string
+	}{arg1, arg2})
+	stub := fake.AnnotateTransactionStub
+	fakeReturns := fake.annotateTransactionReturns
+	fake.recordInvocation("AnnotateTransaction", []interface{}{arg1, arg2})
+	fake.annotateTransactionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) AnnotateTransactionCallCount() int {
+	fake.annotateTransactionMutex.RLock()
+	defer fake.annotateTransactionMutex.RUnlock()
+	return len(fake.annotateTransactionArgsForCall)
+}
+
+func (fake *FakeClient) AnnotateTransactionCalls(stub func(string, map[string]string) (*mondo.Transaction, error)) {
+	fake.annotateTransactionMutex.Lock()
+	defer fake.annotateTransactionMutex.Unlock()
+	fake.AnnotateTransactionStub = stub
+}
+
+func (fake *FakeClient) AnnotateTransactionArgsForCall(i int) (string, map[string]string) {
+	fake.annotateTransactionMutex.RLock()
+	defer fake.annotateTransactionMutex.RUnlock()
+	argsForCall := fake.annotateTransactionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeClient) AnnotateTransactionReturns(result1 *mondo.Transaction, result2 error) {
+	fake.annotateTransactionMutex.Lock()
+	defer fake.annotateTransactionMutex.Unlock()
+	fake.AnnotateTransactionStub = nil
+	fake.annotateTransactionReturns = struct {
+		result1 *mondo.Transaction
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) AnnotateTransactionReturnsOnCall(i int, result1 *mondo.Transaction, result2 error) {
+	fake.annotateTransactionMutex.Lock()
+	defer fake.annotateTransactionMutex.Unlock()
+	fake.AnnotateTransactionStub = nil
+	if fake.annotateTransactionReturnsOnCall == nil {
+		fake.annotateTransactionReturnsOnCall = make(map[int]struct {
+			result1 *mondo.Transaction
+			result2 error
+		})
+	}
+	fake.annotateTransactionReturnsOnCall[i] = struct {
+		result1 *mondo.Transaction
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) CreateFeedItem(arg1 string, arg2 string, arg3 string, arg4 string, arg5 string, arg6 string, arg7 string) error {
+	fake.createFeedItemMutex.Lock()
+	ret, specificReturn := fake.createFeedItemReturnsOnCall[len(fake.createFeedItemArgsForCall)]
+	fake.createFeedItemArgsForCall = append(fake.createFeedItemArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 string
+		arg6 string
+		arg7 string
+	}{arg1, arg2, arg3, arg4, arg5, arg6, arg7})
+	stub := fake.CreateFeedItemStub
+	fakeReturns := fake.createFeedItemReturns
+	fake.recordInvocation("CreateFeedItem", []interface{}{arg1, arg2, arg3, arg4, arg5, arg6, arg7})
+	fake.createFeedItemMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5, arg6, arg7)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) CreateFeedItemCallCount() int {
+	fake.createFeedItemMutex.RLock()
+	defer fake.createFeedItemMutex.RUnlock()
+	return len(fake.createFeedItemArgsForCall)
+}
+
+func (fake *FakeClient) CreateFeedItemCalls(stub func(string, string, string, string, string, string, string) error) {
+	fake.createFeedItemMutex.Lock()
+	defer fake.createFeedItemMutex.Unlock()
+	fake.CreateFeedItemStub = stub
+}
+
+func (fake *FakeClient) CreateFeedItemArgsForCall(i int) (string, string, string, string, string, string, string) {
+	fake.createFeedItemMutex.RLock()
+	defer fake.createFeedItemMutex.RUnlock()
+	argsForCall := fake.createFeedItemArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5, argsForCall.arg6, argsForCall.arg7
+}
+
+func (fake *FakeClient) CreateFeedItemReturns(result1 error) {
+	fake.createFeedItemMutex.Lock()
+	defer fake.createFeedItemMutex.Unlock()
+	fake.CreateFeedItemStub = nil
+	fake.createFeedItemReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) CreateFeedItemReturnsOnCall(i int, result1 error) {
+	fake.createFeedItemMutex.Lock()
+	defer fake.createFeedItemMutex.Unlock()
+	fake.CreateFeedItemStub = nil
+	if fake.createFeedItemReturnsOnCall == nil {
+		fake.createFeedItemReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.createFeedItemReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) PostFeedItem(arg1 string, arg2 *mondo.FeedItem) error {
+	fake.postFeedItemMutex.Lock()
+	ret, specificReturn := fake.postFeedItemReturnsOnCall[len(fake.postFeedItemArgsForCall)]
+	fake.postFeedItemArgsForCall = append(fake.postFeedItemArgsForCall, struct {
+		arg1 string
+		arg2 *mondo.FeedItem
+	}{arg1, arg2})
+	stub := fake.PostFeedItemStub
+	fakeReturns := fake.postFeedItemReturns
+	fake.recordInvocation("PostFeedItem", []interface{}{arg1, arg2})
+	fake.postFeedItemMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) PostFeedItemCallCount() int {
+	fake.postFeedItemMutex.RLock()
+	defer fake.postFeedItemMutex.RUnlock()
+	return len(fake.postFeedItemArgsForCall)
+}
+
+func (fake *FakeClient) PostFeedItemCalls(stub func(string, *mondo.FeedItem) error) {
+	fake.postFeedItemMutex.Lock()
+	defer fake.postFeedItemMutex.Unlock()
+	fake.PostFeedItemStub = stub
+}
+
+func (fake *FakeClient) PostFeedItemArgsForCall(i int) (string, *mondo.FeedItem) {
+	fake.postFeedItemMutex.RLock()
+	defer fake.postFeedItemMutex.RUnlock()
+	argsForCall := fake.postFeedItemArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeClient) PostFeedItemReturns(result1 error) {
+	fake.postFeedItemMutex.Lock()
+	defer fake.postFeedItemMutex.Unlock()
+	fake.PostFeedItemStub = nil
+	fake.postFeedItemReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) PostFeedItemReturnsOnCall(i int, result1 error) {
+	fake.postFeedItemMutex.Lock()
+	defer fake.postFeedItemMutex.Unlock()
+	fake.PostFeedItemStub = nil
+	if fake.postFeedItemReturnsOnCall == nil {
+		fake.postFeedItemReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.postFeedItemReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) RegisterWebhook(arg1 string, arg2 string) (*mondo.Webhook, error) {
+	fake.registerWebhookMutex.Lock()
+	ret, specificReturn := fake.registerWebhookReturnsOnCall[len(fake.registerWebhookArgsForCall)]
+	fake.registerWebhookArgsForCall = append(fake.registerWebhookArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.RegisterWebhookStub
+	fakeReturns := fake.registerWebhookReturns
+	fake.recordInvocation("RegisterWebhook", []interface{}{arg1, arg2})
+	fake.registerWebhookMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) RegisterWebhookCallCount() int {
+	fake.registerWebhookMutex.RLock()
+	defer fake.registerWebhookMutex.RUnlock()
+	return len(fake.registerWebhookArgsForCall)
+}
+
+func (fake *FakeClient) RegisterWebhookCalls(stub func(string, string) (*mondo.Webhook, error)) {
+	fake.registerWebhookMutex.Lock()
+	defer fake.registerWebhookMutex.Unlock()
+	fake.RegisterWebhookStub = stub
+}
+
+func (fake *FakeClient) RegisterWebhookArgsForCall(i int) (string, string) {
+	fake.registerWebhookMutex.RLock()
+	defer fake.registerWebhookMutex.RUnlock()
+	argsForCall := fake.registerWebhookArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeClient) RegisterWebhookReturns(result1 *mondo.Webhook, result2 error) {
+	fake.registerWebhookMutex.Lock()
+	defer fake.registerWebhookMutex.Unlock()
+	fake.RegisterWebhookStub = nil
+	fake.registerWebhookReturns = struct {
+		result1 *mondo.Webhook
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) RegisterWebhookReturnsOnCall(i int, result1 *mondo.Webhook, result2 error) {
+	fake.registerWebhookMutex.Lock()
+	defer fake.registerWebhookMutex.Unlock()
+	fake.RegisterWebhookStub = nil
+	if fake.registerWebhookReturnsOnCall == nil {
+		fake.registerWebhookReturnsOnCall = make(map[int]struct {
+			result1 *mondo.Webhook
+			result2 error
+		})
+	}
+	fake.registerWebhookReturnsOnCall[i] = struct {
+		result1 *mondo.Webhook
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) ListWebhooks(arg1 string) ([]mondo.Webhook, error) {
+	fake.listWebhooksMutex.Lock()
+	ret, specificReturn := fake.listWebhooksReturnsOnCall[len(fake.listWebhooksArgsForCall)]
+	fake.listWebhooksArgsForCall = append(fake.listWebhooksArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ListWebhooksStub
+	fakeReturns := fake.listWebhooksReturns
+	fake.recordInvocation("ListWebhooks", []interface{}{arg1})
+	fake.listWebhooksMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) ListWebhooksCallCount() int {
+	fake.listWebhooksMutex.RLock()
+	defer fake.listWebhooksMutex.RUnlock()
+	return len(fake.listWebhooksArgsForCall)
+}
+
+func (fake *FakeClient) ListWebhooksCalls(stub func(string) ([]mondo.Webhook, error)) {
+	fake.listWebhooksMutex.Lock()
+	defer fake.listWebhooksMutex.Unlock()
+	fake.ListWebhooksStub = stub
+}
+
+func (fake *FakeClient) ListWebhooksArgsForCall(i int) string {
+	fake.listWebhooksMutex.RLock()
+	defer fake.listWebhooksMutex.RUnlock()
+	argsForCall := fake.listWebhooksArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeClient) ListWebhooksReturns(result1 []mondo.Webhook, result2 error) {
+	fake.listWebhooksMutex.Lock()
+	defer fake.listWebhooksMutex.Unlock()
+	fake.ListWebhooksStub = nil
+	fake.listWebhooksReturns = struct {
+		result1 []mondo.Webhook
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) ListWebhooksReturnsOnCall(i int, result1 []mondo.Webhook, result2 error) {
+	fake.listWebhooksMutex.Lock()
+	defer fake.listWebhooksMutex.Unlock()
+	fake.ListWebhooksStub = nil
+	if fake.listWebhooksReturnsOnCall == nil {
+		fake.listWebhooksReturnsOnCall = make(map[int]struct {
+			result1 []mondo.Webhook
+			result2 error
+		})
+	}
+	fake.listWebhooksReturnsOnCall[i] = struct {
+		result1 []mondo.Webhook
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) DeleteWebhook(arg1 string) error {
+	fake.deleteWebhookMutex.Lock()
+	ret, specificReturn := fake.deleteWebhookReturnsOnCall[len(fake.deleteWebhookArgsForCall)]
+	fake.deleteWebhookArgsForCall = append(fake.deleteWebhookArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.DeleteWebhookStub
+	fakeReturns := fake.deleteWebhookReturns
+	fake.recordInvocation("DeleteWebhook", []interface{}{arg1})
+	fake.deleteWebhookMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) DeleteWebhookCallCount() int {
+	fake.deleteWebhookMutex.RLock()
+	defer fake.deleteWebhookMutex.RUnlock()
+	return len(fake.deleteWebhookArgsForCall)
+}
+
+func (fake *FakeClient) DeleteWebhookCalls(stub func(string) error) {
+	fake.deleteWebhookMutex.Lock()
+	defer fake.deleteWebhookMutex.Unlock()
+	fake.DeleteWebhookStub = stub
+}
+
+func (fake *FakeClient) DeleteWebhookArgsForCall(i int) string {
+	fake.deleteWebhookMutex.RLock()
+	defer fake.deleteWebhookMutex.RUnlock()
+	argsForCall := fake.deleteWebhookArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeClient) DeleteWebhookReturns(result1 error) {
+	fake.deleteWebhookMutex.Lock()
+	defer fake.deleteWebhookMutex.Unlock()
+	fake.DeleteWebhookStub = nil
+	fake.deleteWebhookReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) DeleteWebhookReturnsOnCall(i int, result1 error) {
+	fake.deleteWebhookMutex.Lock()
+	defer fake.deleteWebhookMutex.Unlock()
+	fake.DeleteWebhookStub = nil
+	if fake.deleteWebhookReturnsOnCall == nil {
+		fake.deleteWebhookReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteWebhookReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) RegisterAttachment(arg1 string, arg2 string, arg3 string) (*mondo.Attachment, error) {
+	fake.registerAttachmentMutex.Lock()
+	ret, specificReturn := fake.registerAttachmentReturnsOnCall[len(fake.registerAttachmentArgsForCall)]
+	fake.registerAttachmentArgsForCall = append(fake.registerAttachmentArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.RegisterAttachmentStub
+	fakeReturns := fake.registerAttachmentReturns
+	fake.recordInvocation("RegisterAttachment", []interface{}{arg1, arg2, arg3})
+	fake.registerAttachmentMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) RegisterAttachmentCallCount() int {
+	fake.registerAttachmentMutex.RLock()
+	defer fake.registerAttachmentMutex.RUnlock()
+	return len(fake.registerAttachmentArgsForCall)
+}
+
+func (fake *FakeClient) RegisterAttachmentCalls(stub func(string, string, string) (*mondo.Attachment, error)) {
+	fake.registerAttachmentMutex.Lock()
+	defer fake.registerAttachmentMutex.Unlock()
+	fake.RegisterAttachmentStub = stub
+}
+
+func (fake *FakeClient) RegisterAttachmentArgsForCall(i int) (string, string, string) {
+	fake.registerAttachmentMutex.RLock()
+	defer fake.registerAttachmentMutex.RUnlock()
+	argsForCall := fake.registerAttachmentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeClient) RegisterAttachmentReturns(result1 *mondo.Attachment, result2 error) {
+	fake.registerAttachmentMutex.Lock()
+	defer fake.registerAttachmentMutex.Unlock()
+	fake.RegisterAttachmentStub = nil
+	fake.registerAttachmentReturns = struct {
+		result1 *mondo.Attachment
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) RegisterAttachmentReturnsOnCall(i int, result1 *mondo.Attachment, result2 error) {
+	fake.registerAttachmentMutex.Lock()
+	defer fake.registerAttachmentMutex.Unlock()
+	fake.RegisterAttachmentStub = nil
+	if fake.registerAttachmentReturnsOnCall == nil {
+		fake.registerAttachmentReturnsOnCall = make(map[int]struct {
+			result1 *mondo.Attachment
+			result2 error
+		})
+	}
+	fake.registerAttachmentReturnsOnCall[i] = struct {
+		result1 *mondo.Attachment
+		result2 error
+	}{result1, result2}
+}
+
+// Invocations returns the arguments of every call made to the fake, keyed by method name.
+func (fake *FakeClient) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeClient) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
@@ -0,0 +1,49 @@
+package mondotest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/stretchr/testify/assert"
+)
+
+// latestBalance is an example of code that depends on mondo.Client.
+func latestBalance(c mondo.Client, accountId string) (int, error) {
+	transactions, err := c.Transactions(accountId, "", "", 1)
+	if err != nil {
+		return 0, err
+	}
+	if len(transactions) == 0 {
+		return 0, nil
+	}
+	return transactions[0].AccountBalance, nil
+}
+
+func TestFakeClient(t *testing.T) {
+	fake := &FakeClient{}
+	fake.TransactionsReturns([]mondo.Transaction{{AccountBalance: 1234}}, nil)
+	fake.TransactionsReturnsOnCall(1, nil, fmt.Errorf("boom"))
+
+	balance, err := latestBalance(fake, "acc_1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1234, balance)
+
+	_, err = latestBalance(fake, "acc_2")
+	assert.Error(t, err)
+
+	assert.Equal(t, 2, fake.TransactionsCallCount())
+	accountId, since, before, limit := fake.TransactionsArgsForCall(1)
+	assert.Equal(t, "acc_2", accountId)
+	assert.Equal(t, "", since)
+	assert.Equal(t, "", before)
+	assert.Equal(t, 1, limit)
+
+	fake.DeleteWebhookCalls(func(id string) error {
+		return fmt.Errorf("no webhook %v", id)
+	})
+	assert.EqualError(t, fake.DeleteWebhook("webhook_1"), "no webhook webhook_1")
+
+	assert.Equal(t, 0, fake.AccountsCallCount())
+	assert.Equal(t, 2, len(fake.Invocations()["Transactions"]))
+}
@@ -0,0 +1,543 @@
+// Package mondotest provides an in-process fake of the Mondo API for use in tests.
+//
+// A Server holds accounts, transactions, feed items, webhooks and attachments in memory, issues and expires oauth tokens, and records every call made against it so that tests can assert on them.
+//
+//	srv := mondotest.NewServer()
+//	defer srv.Close()
+//
+//	srv.AddAccount(mondo.Account{ID: "acc_1", Description: "Peter Pan's Account"})
+//	srv.AddTransactions("acc_1", mondo.Transaction{ID: "tx_1", Amount: -510, Created: "2015-08-22T12:20:18Z"})
+//
+//	client, err := mondo.Authenticate("id", "secret", "user", "pass")
+package mondotest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sjwhitworth/gomondo"
+)
+
+var (
+	// The lifetime of tokens issued by a Server, unless changed with SetTokenTTL.
+	DefaultTokenTTL = 6 * time.Hour
+
+	// The maximum number of transactions returned in a single page, matching the real API.
+	MaxPageSize = 100
+)
+
+// Call is a request received by a Server.
+type Call struct {
+	Method string
+	Path   string
+	Params url.Values
+	Status int
+}
+
+// FeedItem is a feed item posted to a Server.
+type FeedItem struct {
+	AccountID string
+	Type      string
+	URL       string
+	Params    map[string]string
+}
+
+type user struct {
+	clientId, clientSecret, username, password string
+}
+
+type token struct {
+	userId  string
+	expires time.Time
+}
+
+// refreshGrant is what a refresh token may be exchanged for.
+type refreshGrant struct {
+	userId, clientId, clientSecret string
+}
+
+// Server is a stateful fake Mondo API. It is safe for concurrent use.
+type Server struct {
+	// URL is the base URL of the fake, of the form http://ipaddr:port with no trailing slash.
+	URL string
+
+	srv         *httptest.Server
+	prevBaseURL string
+
+	mu           sync.Mutex
+	ttl          time.Duration
+	users        []user
+	tokens       map[string]token
+	refresh      map[string]refreshGrant
+	accounts     []mondo.Account
+	transactions map[string][]mondo.Transaction
+	feed         []FeedItem
+	webhooks     []mondo.Webhook
+	attachments  []mondo.Attachment
+	calls        []Call
+	nextId       int
+}
+
+// NewServer starts a fake Mondo API and points mondo.BaseMondoURL at it. The previous value of mondo.BaseMondoURL is restored by Close.
+func NewServer() *Server {
+	s := &Server{
+		ttl:          DefaultTokenTTL,
+		tokens:       map[string]token{},
+		refresh:      map[string]refreshGrant{},
+		transactions: map[string][]mondo.Transaction{},
+	}
+
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL
+	s.prevBaseURL = mondo.BaseMondoURL
+	mondo.BaseMondoURL = s.URL
+	return s
+}
+
+// Close shuts down the server and restores mondo.BaseMondoURL.
+func (s *Server) Close() {
+	s.srv.Close()
+	mondo.BaseMondoURL = s.prevBaseURL
+}
+
+// AddUser registers a set of credentials accepted by the oauth2/token endpoint. Until a user is added, any non-empty credentials are accepted.
+func (s *Server) AddUser(clientId, clientSecret, username, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users = append(s.users, user{clientId, clientSecret, username, password})
+}
+
+// SetTokenTTL sets the lifetime of tokens issued from now on.
+func (s *Server) SetTokenTTL(ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ttl = ttl
+}
+
+// ExpireTokens invalidates every access token issued so far. Subsequent calls made with them receive a 401, and clients must exchange their refresh token for a new one.
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = map[string]token{}
+}
+
+// RevokeRefreshTokens invalidates every refresh token issued so far, so that clients must authenticate again once their access token expires.
+func (s *Server) RevokeRefreshTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh = map[string]refreshGrant{}
+}
+
+// AddAccount seeds the server with an account.
+func (s *Server) AddAccount(accounts ...mondo.Account) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accounts = append(s.accounts, accounts...)
+}
+
+// AddTransactions seeds an account with transactions. Transactions are served in order of their Created time, and are given an ID if they do not have one.
+func (s *Server) AddTransactions(accountId string, transactions ...mondo.Transaction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tx := range transactions {
+		if tx.ID == "" {
+			tx.ID = s.newId("tx")
+		}
+		s.transactions[accountId] = append(s.transactions[accountId], tx)
+	}
+
+	txs := s.transactions[accountId]
+	sort.SliceStable(txs, func(i, j int) bool { return txs[i].Created < txs[j].Created })
+}
+
+// FeedItems returns the feed items posted to an account.
+func (s *Server) FeedItems(accountId string) []FeedItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []FeedItem
+	for _, item := range s.feed {
+		if item.AccountID == accountId {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Webhooks returns the webhooks currently registered.
+func (s *Server) Webhooks() []mondo.Webhook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]mondo.Webhook(nil), s.webhooks...)
+}
+
+// Attachments returns the attachments registered.
+func (s *Server) Attachments() []mondo.Attachment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]mondo.Attachment(nil), s.attachments...)
+}
+
+// Calls returns every request the server has received, in order.
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// CallsTo returns the requests the server has received for a method and path, such as "GET" and "/transactions".
+func (s *Server) CallsTo(method, path string) []Call {
+	var calls []Call
+	for _, c := range s.Calls() {
+		if c.Method == method && c.Path == path {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// newId returns a unique ID with the given prefix. Must be called with s.mu held.
+func (s *Server) newId(prefix string) string {
+	s.nextId++
+	return fmt.Sprintf("%v_%08d", prefix, s.nextId)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.route(rec, r)
+	s.calls = append(s.calls, Call{Method: r.Method, Path: r.URL.Path, Params: r.Form, Status: rec.status})
+}
+
+// route dispatches a request. Must be called with s.mu held.
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	if path == "/oauth2/token" && r.Method == "POST" {
+		s.token(w, r)
+		return
+	}
+
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "unauthorized.bad_access_token", "Invalid access token")
+		return
+	}
+
+	switch {
+	case path == "/accounts" && r.Method == "GET":
+		writeJSON(w, map[string]interface{}{"accounts": s.accounts})
+	case path == "/balance" && r.Method == "GET":
+		s.balance(w, r)
+	case path == "/transactions" && r.Method == "GET":
+		s.listTransactions(w, r)
+	case strings.HasPrefix(path, "/transactions/") && r.Method == "GET":
+		s.transaction(w, r, strings.TrimPrefix(path, "/transactions/"))
+	case strings.HasPrefix(path, "/transactions/") && r.Method == "PATCH":
+		s.annotateTransaction(w, r, strings.TrimPrefix(path, "/transactions/"))
+	case path == "/feed" && r.Method == "POST":
+		s.createFeedItem(w, r)
+	case path == "/webhooks" && r.Method == "POST":
+		s.registerWebhook(w, r)
+	case path == "/webhooks" && r.Method == "GET":
+		s.listWebhooks(w, r)
+	case strings.HasPrefix(path, "/webhooks/") && r.Method == "DELETE":
+		s.deleteWebhook(w, strings.TrimPrefix(path, "/webhooks/"))
+	case path == "/attachment/register" && r.Method == "POST":
+		s.registerAttachment(w, r)
+	default:
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("No handler for %v %v", r.Method, path))
+	}
+}
+
+// token issues an access token for a password or refresh token grant. Refresh tokens can only be used once, as a new one is issued with each access token.
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	var userId, clientId, clientSecret string
+
+	switch r.FormValue("grant_type") {
+	case mondo.GrantTypePassword:
+		u := user{r.FormValue("client_id"), r.FormValue("client_secret"), r.FormValue("username"), r.FormValue("password")}
+		if !s.validUser(u) {
+			writeError(w, http.StatusUnauthorized, "unauthorized.bad_credentials", "Invalid credentials")
+			return
+		}
+		userId, clientId, clientSecret = "user_"+u.username, u.clientId, u.clientSecret
+
+	case mondo.GrantTypeRefreshToken:
+		grant, ok := s.refresh[r.FormValue("refresh_token")]
+		if !ok || grant.clientId != r.FormValue("client_id") || grant.clientSecret != r.FormValue("client_secret") {
+			writeError(w, http.StatusUnauthorized, "unauthorized.bad_refresh_token", "Invalid refresh token")
+			return
+		}
+		delete(s.refresh, r.FormValue("refresh_token"))
+		userId, clientId, clientSecret = grant.userId, grant.clientId, grant.clientSecret
+
+	default:
+		writeJSON(w, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	accessToken := s.newId("token")
+	s.tokens[accessToken] = token{userId: userId, expires: time.Now().Add(s.ttl)}
+	refreshToken := s.newId("refresh")
+	s.refresh[refreshToken] = refreshGrant{userId, clientId, clientSecret}
+
+	writeJSON(w, map[string]interface{}{
+		"access_token":  accessToken,
+		"client_id":     clientId,
+		"expires_in":    int(s.ttl.Seconds()),
+		"refresh_token": refreshToken,
+		"token_type":    "Bearer",
+		"user_id":       userId,
+	})
+}
+
+func (s *Server) validUser(u user) bool {
+	if len(s.users) == 0 {
+		return u.clientId != "" && u.clientSecret != "" && u.username != "" && u.password != ""
+	}
+
+	for _, known := range s.users {
+		if known == u {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	accessToken := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	tok, ok := s.tokens[accessToken]
+	return ok && time.Now().Before(tok.expires)
+}
+
+// balance reports the account_balance of the latest transaction, and the sum of today's spending.
+func (s *Server) balance(w http.ResponseWriter, r *http.Request) {
+	accountId := r.FormValue("account_id")
+	if !s.hasAccount(accountId) {
+		writeError(w, http.StatusBadRequest, "bad_request.bad_param.account_id", "Unknown account")
+		return
+	}
+
+	balance := mondo.Balance{Currency: "GBP"}
+	today := time.Now().UTC().Format("2006-01-02")
+	for _, tx := range s.transactions[accountId] {
+		balance.Balance = tx.AccountBalance
+		if tx.Currency != "" {
+			balance.Currency = tx.Currency
+		}
+		if strings.HasPrefix(tx.Created, today) && tx.Amount < 0 && !tx.IsLoad {
+			balance.SpendToday += tx.Amount
+		}
+	}
+
+	writeJSON(w, balance)
+}
+
+func (s *Server) listTransactions(w http.ResponseWriter, r *http.Request) {
+	accountId := r.FormValue("account_id")
+	if accountId == "" {
+		writeError(w, http.StatusBadRequest, "bad_request.missing_param.account_id", "Missing account_id")
+		return
+	}
+
+	limit := MaxPageSize
+	if v := r.FormValue("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, "bad_request.bad_param.limit", "Invalid limit")
+			return
+		}
+		if n < limit {
+			limit = n
+		}
+	}
+
+	since, before := r.FormValue("since"), r.FormValue("before")
+	txs := s.transactions[accountId]
+
+	// since is either a transaction ID, in which case we page after it, or a timestamp.
+	start := 0
+	if since != "" {
+		if _, err := time.Parse(time.RFC3339, since); err == nil {
+			for start < len(txs) && txs[start].Created < since {
+				start++
+			}
+		} else {
+			for i, tx := range txs {
+				if tx.ID == since {
+					start = i + 1
+					break
+				}
+			}
+		}
+	}
+
+	page := []mondo.Transaction{}
+	for _, tx := range txs[start:] {
+		if len(page) == limit || (before != "" && tx.Created >= before) {
+			break
+		}
+		page = append(page, tx)
+	}
+
+	writeJSON(w, map[string]interface{}{"transactions": page})
+}
+
+func (s *Server) transaction(w http.ResponseWriter, r *http.Request, transactionId string) {
+	for accountId, txs := range s.transactions {
+		if v := r.FormValue("account_id"); v != "" && v != accountId {
+			continue
+		}
+		for _, tx := range txs {
+			if tx.ID == transactionId {
+				writeJSON(w, map[string]interface{}{"transaction": tx})
+				return
+			}
+		}
+	}
+	writeError(w, http.StatusNotFound, "not_found", "Transaction not found")
+}
+
+// annotateTransaction sets the metadata[key] parameters of a request on a transaction. Empty values delete their key.
+func (s *Server) annotateTransaction(w http.ResponseWriter, r *http.Request, transactionId string) {
+	for _, txs := range s.transactions {
+		for i := range txs {
+			tx := &txs[i]
+			if tx.ID != transactionId {
+				continue
+			}
+
+			for k, v := range r.PostForm {
+				if !strings.HasPrefix(k, "metadata[") || !strings.HasSuffix(k, "]") {
+					continue
+				}
+				key := k[len("metadata[") : len(k)-1]
+
+				if tx.Metadata == nil {
+					tx.Metadata = map[string]interface{}{}
+				}
+				if v[0] == "" {
+					delete(tx.Metadata, key)
+				} else {
+					tx.Metadata[key] = v[0]
+				}
+			}
+
+			writeJSON(w, map[string]interface{}{"transaction": tx})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "not_found", "Transaction not found")
+}
+
+func (s *Server) hasAccount(accountId string) bool {
+	for _, ac := range s.accounts {
+		if ac.ID == accountId {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) createFeedItem(w http.ResponseWriter, r *http.Request) {
+	accountId := r.FormValue("account_id")
+	if !s.hasAccount(accountId) {
+		writeError(w, http.StatusBadRequest, "bad_request.bad_param.account_id", "Unknown account")
+		return
+	}
+
+	if r.FormValue("type") == "" {
+		writeError(w, http.StatusBadRequest, "bad_request.missing_param.type", "Missing type")
+		return
+	}
+
+	item := FeedItem{AccountID: accountId, Type: r.FormValue("type"), URL: r.FormValue("url"), Params: map[string]string{}}
+	for k := range r.PostForm {
+		if strings.HasPrefix(k, "params[") && strings.HasSuffix(k, "]") {
+			item.Params[k[len("params["):len(k)-1]] = r.PostForm.Get(k)
+		}
+	}
+	s.feed = append(s.feed, item)
+
+	writeJSON(w, map[string]interface{}{})
+}
+
+func (s *Server) registerWebhook(w http.ResponseWriter, r *http.Request) {
+	accountId := r.FormValue("account_id")
+	if !s.hasAccount(accountId) {
+		writeError(w, http.StatusBadRequest, "bad_request.bad_param.account_id", "Unknown account")
+		return
+	}
+
+	webhook := mondo.Webhook{AccountId: accountId, Id: s.newId("webhook"), Url: r.FormValue("url")}
+	s.webhooks = append(s.webhooks, webhook)
+	writeJSON(w, map[string]interface{}{"webhook": webhook})
+}
+
+func (s *Server) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks := []mondo.Webhook{}
+	for _, webhook := range s.webhooks {
+		if webhook.AccountId == r.FormValue("account_id") {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	writeJSON(w, map[string]interface{}{"webhooks": webhooks})
+}
+
+func (s *Server) deleteWebhook(w http.ResponseWriter, webhookId string) {
+	for i, webhook := range s.webhooks {
+		if webhook.Id == webhookId {
+			s.webhooks = append(s.webhooks[:i], s.webhooks[i+1:]...)
+			writeJSON(w, map[string]interface{}{})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "not_found", "Webhook not found")
+}
+
+func (s *Server) registerAttachment(w http.ResponseWriter, r *http.Request) {
+	attachment := mondo.Attachment{
+		Id:         s.newId("attach"),
+		UserId:     s.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")].userId,
+		ExternalId: r.FormValue("external_id"),
+		FileUrl:    r.FormValue("file_url"),
+		FileType:   r.FormValue("file_type"),
+		Created:    time.Now().UTC().Format(time.RFC3339),
+	}
+	s.attachments = append(s.attachments, attachment)
+	writeJSON(w, map[string]interface{}{"attachment": attachment})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"code": code, "message": message})
+}
+
+// statusRecorder captures the status code written to a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,107 @@
+package mondotest
+
+import (
+	"testing"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.AddUser("client", "secret", "user", "pass")
+	srv.AddAccount(mondo.Account{ID: "acc_1", Description: "Peter Pan's Account"})
+	srv.AddTransactions("acc_1",
+		mondo.Transaction{ID: "tx_3", Amount: -300, Created: "2015-08-24T12:00:00Z"},
+		mondo.Transaction{ID: "tx_1", Amount: -100, Created: "2015-08-22T12:00:00Z"},
+		mondo.Transaction{ID: "tx_2", Amount: -200, Created: "2015-08-23T12:00:00Z"},
+	)
+
+	_, err := mondo.Authenticate("client", "wrong", "user", "pass")
+	assert.Equal(t, mondo.ErrUnauthenticatedRequest, err)
+
+	client, err := mondo.Authenticate("client", "secret", "user", "pass")
+	assert.NoError(t, err)
+
+	accounts, err := client.Accounts()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(accounts))
+	assert.Equal(t, "Peter Pan's Account", accounts[0].Description)
+
+	page, err := client.Transactions("acc_1", "", "", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(page))
+	assert.Equal(t, "tx_1", page[0].ID)
+
+	page, err = client.Transactions("acc_1", page[1].ID, "", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(page))
+	assert.Equal(t, "tx_3", page[0].ID)
+
+	page, err = client.Transactions("acc_1", "2015-08-23T00:00:00Z", "2015-08-24T00:00:00Z", 100)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(page))
+	assert.Equal(t, "tx_2", page[0].ID)
+
+	balance, err := client.Balance("acc_1")
+	assert.NoError(t, err)
+	assert.Equal(t, "GBP", balance.Currency)
+
+	tx, err := client.TransactionByID("acc_1", "tx_2")
+	assert.NoError(t, err)
+	assert.Equal(t, -200, tx.Amount)
+
+	_, err = client.TransactionByID("acc_1", "tx_missing")
+	assert.Equal(t, mondo.ErrNoTransactionFound, err)
+
+	tx, err = client.AnnotateTransaction("tx_2", map[string]string{"notes": "lunch"})
+	assert.NoError(t, err)
+	assert.Equal(t, "lunch", tx.Metadata["notes"])
+	tx, err = client.TransactionByID("acc_1", "tx_2")
+	assert.NoError(t, err)
+	assert.Equal(t, "lunch", tx.Metadata["notes"])
+
+	assert.NoError(t, client.CreateFeedItem("acc_1", "Hello!", "http://www.gophers.com/gopher1.png", "", "", "", "A body"))
+	assert.Error(t, client.CreateFeedItem("acc_unknown", "Hello!", "http://www.gophers.com/gopher1.png", "", "", "", "A body"))
+	items := srv.FeedItems("acc_1")
+	assert.Equal(t, 1, len(items))
+	assert.Equal(t, "Hello!", items[0].Params["title"])
+
+	webhook, err := client.RegisterWebhook("acc_1", "http://example.com/hook")
+	assert.NoError(t, err)
+	assert.Equal(t, []mondo.Webhook{*webhook}, srv.Webhooks())
+	assert.NoError(t, client.DeleteWebhook(webhook.Id))
+	assert.Empty(t, srv.Webhooks())
+
+	attachment, err := client.RegisterAttachment("tx_1", "http://example.com/receipt.png", "image/png")
+	assert.NoError(t, err)
+	assert.Equal(t, "tx_1", attachment.ExternalId)
+	assert.Equal(t, 1, len(srv.Attachments()))
+
+	assert.Equal(t, 3, len(srv.CallsTo("GET", "/transactions")))
+	assert.Equal(t, "2", srv.CallsTo("GET", "/transactions")[0].Params.Get("limit"))
+
+	// An expired token is refreshed, but only once the refresh token is still valid.
+	srv.ExpireTokens()
+	_, err = client.Accounts()
+	assert.NoError(t, err)
+	tokenCalls := srv.CallsTo("POST", "/oauth2/token")
+	assert.Equal(t, mondo.GrantTypeRefreshToken, tokenCalls[len(tokenCalls)-1].Params.Get("grant_type"))
+
+	srv.ExpireTokens()
+	srv.RevokeRefreshTokens()
+	_, err = client.Accounts()
+	assert.Equal(t, mondo.ErrUnauthenticatedRequest, err)
+}
+
+func TestServerRestoresBaseURL(t *testing.T) {
+	prev := mondo.BaseMondoURL
+
+	srv := NewServer()
+	assert.Equal(t, srv.URL, mondo.BaseMondoURL)
+	srv.Close()
+
+	assert.Equal(t, prev, mondo.BaseMondoURL)
+}
@@ -0,0 +1,311 @@
+// Package multiuser manages Mondo clients for several users, such as the members of a household, and fans calls out across all of them.
+//
+// Each user's client is authenticated, refreshed and expired on its own, so one user's token expiring or credentials being revoked does not affect the others. Calls that fan out return whatever succeeded alongside an Errors value recording which users failed and why.
+package multiuser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sjwhitworth/gomondo"
+)
+
+// The number of users called at once, unless a Manager sets its own.
+var DefaultParallelism = 4
+
+// ErrUnknownUser is returned for a user ID the Manager does not hold.
+var ErrUnknownUser = fmt.Errorf("unknown user")
+
+// ErrExpired is returned for a user whose client has expired and who has no credentials to authenticate again with.
+var ErrExpired = fmt.Errorf("client has expired and there are no credentials to refresh it with")
+
+// Credentials are what a user is authenticated with.
+type Credentials struct {
+	ClientID     string
+	ClientSecret string
+	Username     string
+	Password     string
+}
+
+// Manager holds a MondoClient per user, keyed by user ID. It is safe for concurrent use.
+type Manager struct {
+	// Parallelism is the most users called at once when fanning out. Defaults to DefaultParallelism.
+	Parallelism int
+
+	// Authenticate is used to authenticate users. Defaults to mondo.Authenticate.
+	Authenticate func(clientId, clientSecret, username, password string) (*mondo.MondoClient, error)
+
+	mu    sync.Mutex
+	users map[string]*user
+}
+
+// user is a client, and the credentials to replace it with when it expires. mu is held while the client is in use, so each user's calls are made one at a time.
+type user struct {
+	mu     sync.Mutex
+	creds  *Credentials
+	client *mondo.MondoClient
+}
+
+// NewManager returns a Manager with no users.
+func NewManager() *Manager {
+	return &Manager{
+		Parallelism:  DefaultParallelism,
+		Authenticate: mondo.Authenticate,
+		users:        map[string]*user{},
+	}
+}
+
+// AddUser adds a user who is authenticated with creds the first time they are called, and again whenever their token expires. It replaces any user with the same ID.
+func (m *Manager) AddUser(userId string, creds Credentials) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.users[userId] = &user{creds: &creds}
+}
+
+// AddClient adds a user with a client that is already authenticated. Once its token expires, calls for the user fail with ErrExpired until the user is added again.
+func (m *Manager) AddClient(userId string, client *mondo.MondoClient) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.users[userId] = &user{client: client}
+}
+
+// Remove removes a user.
+func (m *Manager) Remove(userId string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.users, userId)
+}
+
+// Users returns the IDs of the users held, in order.
+func (m *Manager) Users() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var ids []string
+	for id := range m.users {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Expire discards a user's client, so that they are authenticated again the next time they are called. A user added with AddClient fails with ErrExpired from then on.
+func (m *Manager) Expire(userId string) error {
+	u, err := m.user(userId)
+	if err != nil {
+		return err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.client = nil
+	return nil
+}
+
+// Do calls fn with a user's client, authenticating the user first if their client has expired. If fn fails because the token was rejected, the user is authenticated again and fn retried once.
+func (m *Manager) Do(userId string, fn func(client *mondo.MondoClient) error) error {
+	u, err := m.user(userId)
+	if err != nil {
+		return err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	client, err := m.refresh(u)
+	if err != nil {
+		return err
+	}
+
+	err = fn(client)
+	if err != mondo.ErrUnauthenticatedRequest || u.creds == nil {
+		return err
+	}
+
+	u.client = nil
+	client, err = m.refresh(u)
+	if err != nil {
+		return err
+	}
+	return fn(client)
+}
+
+// Each calls fn for every user, with at most Parallelism users at once, and returns an Errors of the users it failed for, or nil if it succeeded for all of them.
+func (m *Manager) Each(fn func(userId string, client *mondo.MondoClient) error) error {
+	parallelism := m.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	errs := Errors{}
+	sem := make(chan struct{}, parallelism)
+
+	for _, id := range m.Users() {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			err := m.Do(id, func(client *mondo.MondoClient) error {
+				return fn(id, client)
+			})
+			if err != nil {
+				mu.Lock()
+				errs[id] = err
+				mu.Unlock()
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Account is an account and the user it belongs to.
+type Account struct {
+	UserID string `json:"user_id"`
+	mondo.Account
+}
+
+// Accounts returns the accounts of every user, ordered by user and then as the API returns them. If some users fail, the accounts of the rest are returned with an Errors.
+func (m *Manager) Accounts() ([]Account, error) {
+	var mu sync.Mutex
+	byUser := map[string][]mondo.Account{}
+
+	err := m.Each(func(userId string, client *mondo.MondoClient) error {
+		acs, err := client.Accounts()
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		byUser[userId] = acs
+		mu.Unlock()
+		return nil
+	})
+
+	var ids []string
+	for id := range byUser {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var accounts []Account
+	for _, id := range ids {
+		for _, ac := range byUser[id] {
+			accounts = append(accounts, Account{UserID: id, Account: ac})
+		}
+	}
+	return accounts, err
+}
+
+// Transaction is a transaction and the user whose account it is on.
+type Transaction struct {
+	UserID string `json:"user_id"`
+	mondo.Transaction
+}
+
+// Transactions returns the transactions on every account of every user created between since and before, either of which may be empty, merged in order of creation. If some users fail, the transactions of the rest are returned with an Errors. A user's transactions are only returned if every one of their accounts was read.
+func (m *Manager) Transactions(since, before string) ([]Transaction, error) {
+	var mu sync.Mutex
+	var transactions []Transaction
+
+	err := m.Each(func(userId string, client *mondo.MondoClient) error {
+		acs, err := client.Accounts()
+		if err != nil {
+			return err
+		}
+
+		var found []Transaction
+		for _, ac := range acs {
+			err := mondo.EachPage(client, ac.ID, since, before, 0, func(page []mondo.Transaction) error {
+				for _, tx := range page {
+					if tx.AccountID == "" {
+						tx.AccountID = ac.ID
+					}
+					found = append(found, Transaction{UserID: userId, Transaction: tx})
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		mu.Lock()
+		transactions = append(transactions, found...)
+		mu.Unlock()
+		return nil
+	})
+
+	sort.SliceStable(transactions, func(i, j int) bool {
+		a, b := transactions[i], transactions[j]
+		if a.Created != b.Created {
+			return a.Created < b.Created
+		}
+		if a.UserID != b.UserID {
+			return a.UserID < b.UserID
+		}
+		return a.ID < b.ID
+	})
+	return transactions, err
+}
+
+// Errors maps the IDs of users a call failed for to the error it failed with.
+type Errors map[string]error
+
+func (e Errors) Error() string {
+	var parts []string
+	for id, err := range e {
+		parts = append(parts, fmt.Sprintf("%v: %v", id, err))
+	}
+	sort.Strings(parts)
+
+	users := "users"
+	if len(e) == 1 {
+		users = "user"
+	}
+	return fmt.Sprintf("%v %v failed: %v", len(e), users, strings.Join(parts, "; "))
+}
+
+func (m *Manager) user(userId string) (*user, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userId]
+	if !ok {
+		return nil, ErrUnknownUser
+	}
+	return u, nil
+}
+
+// refresh returns a user's client, authenticating them if it is missing or expired. Must be called with u.mu held.
+func (m *Manager) refresh(u *user) (*mondo.MondoClient, error) {
+	if u.client != nil && u.client.Authenticated() {
+		return u.client, nil
+	}
+	if u.creds == nil {
+		return nil, ErrExpired
+	}
+
+	authenticate := m.Authenticate
+	if authenticate == nil {
+		authenticate = mondo.Authenticate
+	}
+
+	client, err := authenticate(u.creds.ClientID, u.creds.ClientSecret, u.creds.Username, u.creds.Password)
+	if err != nil {
+		return nil, err
+	}
+	u.client = client
+	return client, nil
+}
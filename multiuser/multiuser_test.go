@@ -0,0 +1,131 @@
+package multiuser
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/sjwhitworth/gomondo/mondotest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager(t *testing.T) {
+	srv := mondotest.NewServer()
+	defer srv.Close()
+
+	srv.AddUser("client", "secret", "alice", "pass")
+	srv.AddUser("client", "secret", "bob", "pass")
+	srv.AddAccount(mondo.Account{ID: "acc_1"}, mondo.Account{ID: "acc_2"})
+	srv.AddTransactions("acc_1", mondo.Transaction{ID: "tx_1", Created: "2015-08-22T12:00:00Z"}, mondo.Transaction{ID: "tx_3", Created: "2015-08-24T12:00:00Z"})
+	srv.AddTransactions("acc_2", mondo.Transaction{ID: "tx_2", Created: "2015-08-23T12:00:00Z"})
+
+	m := NewManager()
+	m.AddUser("alice", Credentials{"client", "secret", "alice", "pass"})
+	m.AddUser("bob", Credentials{"client", "secret", "bob", "pass"})
+	m.AddUser("carol", Credentials{"client", "secret", "carol", "wrong"})
+	assert.Equal(t, []string{"alice", "bob", "carol"}, m.Users())
+
+	accounts, err := m.Accounts()
+	assert.Equal(t, []Account{
+		{"alice", mondo.Account{ID: "acc_1"}},
+		{"alice", mondo.Account{ID: "acc_2"}},
+		{"bob", mondo.Account{ID: "acc_1"}},
+		{"bob", mondo.Account{ID: "acc_2"}},
+	}, accounts)
+
+	// Carol failing does not stop the others.
+	if assert.IsType(t, Errors{}, err) {
+		errs := err.(Errors)
+		assert.Len(t, errs, 1)
+		assert.Equal(t, mondo.ErrUnauthenticatedRequest, errs["carol"])
+		assert.Equal(t, "1 user failed: carol: your request was not sent with a valid token", err.Error())
+	}
+
+	m.Remove("carol")
+
+	// Each user is authenticated once, and again when their token can no longer be refreshed.
+	assert.Len(t, srv.CallsTo("POST", "/oauth2/token"), 3)
+	srv.ExpireTokens()
+	srv.RevokeRefreshTokens()
+
+	transactions, err := m.Transactions("", "")
+	assert.NoError(t, err)
+	var ids []string
+	for _, tx := range transactions {
+		ids = append(ids, tx.UserID+"/"+tx.AccountID+"/"+tx.ID)
+	}
+	assert.Equal(t, []string{"alice/acc_1/tx_1", "bob/acc_1/tx_1", "alice/acc_2/tx_2", "bob/acc_2/tx_2", "alice/acc_1/tx_3", "bob/acc_1/tx_3"}, ids)
+	assert.Len(t, srv.CallsTo("POST", "/oauth2/token"), 7)
+
+	// Expiring one user only authenticates that user again.
+	assert.NoError(t, m.Expire("alice"))
+	_, err = m.Accounts()
+	assert.NoError(t, err)
+	assert.Len(t, srv.CallsTo("POST", "/oauth2/token"), 8)
+
+	assert.Equal(t, ErrUnknownUser, m.Expire("carol"))
+	assert.Equal(t, ErrUnknownUser, m.Do("carol", func(*mondo.MondoClient) error { return nil }))
+}
+
+func TestAddClient(t *testing.T) {
+	srv := mondotest.NewServer()
+	defer srv.Close()
+	srv.AddAccount(mondo.Account{ID: "acc_1"})
+
+	client, err := mondo.Authenticate("client", "secret", "alice", "pass")
+	assert.NoError(t, err)
+
+	m := NewManager()
+	m.AddClient("alice", client)
+
+	accounts, err := m.Accounts()
+	assert.NoError(t, err)
+	assert.Len(t, accounts, 1)
+
+	// Without credentials, a client that can no longer refresh its token cannot be replaced.
+	srv.ExpireTokens()
+	srv.RevokeRefreshTokens()
+	_, err = m.Accounts()
+	assert.Equal(t, Errors{"alice": mondo.ErrUnauthenticatedRequest}, err)
+
+	m.Expire("alice")
+	_, err = m.Accounts()
+	assert.Equal(t, Errors{"alice": ErrExpired}, err)
+}
+
+func TestParallelism(t *testing.T) {
+	m := NewManager()
+	m.Parallelism = 2
+	m.Authenticate = func(clientId, clientSecret, username, password string) (*mondo.MondoClient, error) {
+		return &mondo.MondoClient{}, nil
+	}
+	for i := 0; i < 6; i++ {
+		m.AddUser(fmt.Sprintf("user_%v", i), Credentials{})
+	}
+
+	var mu sync.Mutex
+	running, most := 0, 0
+	seen := map[string]bool{}
+
+	err := m.Each(func(userId string, client *mondo.MondoClient) error {
+		mu.Lock()
+		running++
+		if running > most {
+			most = running
+		}
+		seen[userId] = true
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, seen, 6)
+	assert.Equal(t, 2, most)
+}
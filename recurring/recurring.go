@@ -0,0 +1,246 @@
+// Package recurring detects subscriptions and other recurring payments in Mondo transactions.
+package recurring
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/sjwhitworth/gomondo"
+)
+
+// Period is how often a recurring payment is charged.
+type Period string
+
+const (
+	Weekly  Period = "weekly"
+	Monthly Period = "monthly"
+	Annual  Period = "annual"
+)
+
+// periods are tried in order. Intervals between charges must be within tolerance of nominal.
+var periods = []struct {
+	period    Period
+	nominal   float64
+	tolerance float64
+}{
+	{Weekly, 7, 1},
+	{Monthly, 30.44, 4},
+	{Annual, 365.25, 10},
+}
+
+// next returns when a payment made at t with this period is next due.
+func (p Period) next(t time.Time) time.Time {
+	switch p {
+	case Weekly:
+		return t.AddDate(0, 0, 7)
+	case Monthly:
+		return t.AddDate(0, 1, 0)
+	}
+	return t.AddDate(1, 0, 0)
+}
+
+// Options tunes detection.
+type Options struct {
+	// AmountTolerance is how much, as a fraction, a charge may differ from the previous charge of a payment and still be part of it. Defaults to 0.25.
+	AmountTolerance float64
+
+	// MinOccurrences is the fewest charges that count as recurring. Defaults to 3, except for annual payments, which need 2.
+	MinOccurrences int
+}
+
+// Payment is a detected recurring payment. Amounts are positive, in minor units such as pence.
+type Payment struct {
+	// Key identifies the merchant, using its group ID so that branches are merged. A merchant with several payments of different amounts, such as two subscriptions, has a Payment for each.
+	Key      string    `json:"key"`
+	Merchant string    `json:"merchant"`
+	Period   Period    `json:"period"`
+	Currency string    `json:"currency"`
+	Count    int       `json:"count"`
+	First    time.Time `json:"first"`
+	Last     time.Time `json:"last"`
+
+	// Amount is the latest charge, and PreviousAmount the one before it.
+	Amount         int `json:"amount"`
+	PreviousAmount int `json:"previous_amount"`
+
+	// NextDate and NextAmount predict the next charge.
+	NextDate   time.Time `json:"next_date"`
+	NextAmount int       `json:"next_amount"`
+
+	TransactionIDs []string `json:"transaction_ids"`
+}
+
+// PriceIncreased reports whether the latest charge was more than the one before it.
+func (p Payment) PriceIncreased() bool {
+	return p.Amount > p.PreviousAmount
+}
+
+// Active reports whether the payment is still expected, meaning its next charge is not overdue by more than a period as of now.
+func (p Payment) Active(now time.Time) bool {
+	return now.Before(p.Period.next(p.NextDate))
+}
+
+type charge struct {
+	mondo.Transaction
+	created time.Time
+}
+
+// Detect finds recurring payments among transactions. Only spending counts; top ups and refunds are ignored. Payments are returned ordered by their next predicted charge.
+func Detect(transactions []mondo.Transaction, opts Options) ([]Payment, error) {
+	if opts.AmountTolerance <= 0 {
+		opts.AmountTolerance = 0.25
+	}
+
+	groups := map[string][]charge{}
+	var keys []string
+	for _, tx := range transactions {
+		if tx.Amount >= 0 || tx.IsLoad {
+			continue
+		}
+
+		created, err := time.Parse(time.RFC3339, tx.Created)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %v has invalid created time: %v", tx.ID, err)
+		}
+
+		key := merchantKey(tx)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], charge{tx, created})
+	}
+
+	var payments []Payment
+	for _, key := range keys {
+		charges := groups[key]
+		sort.SliceStable(charges, func(i, j int) bool { return charges[i].created.Before(charges[j].created) })
+
+		// A merchant can take several payments, such as a subscription alongside one-off purchases, so each run of similar amounts is checked on its own.
+		for _, similar := range byAmount(charges, opts.AmountTolerance) {
+			if p, ok := detect(key, similar, opts); ok {
+				payments = append(payments, p)
+			}
+		}
+	}
+
+	sort.SliceStable(payments, func(i, j int) bool { return payments[i].NextDate.Before(payments[j].NextDate) })
+	return payments, nil
+}
+
+// byAmount splits a merchant's charges, in chronological order, into groups of similar amounts. Each charge joins the group whose latest charge it is closest to, if it is within tolerance of it, which allows for gradual price changes. Groups keep their charges in chronological order.
+func byAmount(charges []charge, tolerance float64) [][]charge {
+	var groups [][]charge
+	for _, c := range charges {
+		best, bestDiff := -1, 0.0
+		for i, g := range groups {
+			prev, cur := float64(-g[len(g)-1].Amount), float64(-c.Amount)
+			diff := math.Abs(cur-prev) / prev
+			if diff <= tolerance && (best < 0 || diff < bestDiff) {
+				best, bestDiff = i, diff
+			}
+		}
+
+		if best < 0 {
+			groups = append(groups, []charge{c})
+			continue
+		}
+		groups[best] = append(groups[best], c)
+	}
+	return groups
+}
+
+// detect checks whether charges of similar amounts at a merchant, in chronological order, recur.
+func detect(key string, charges []charge, opts Options) (Payment, bool) {
+	if len(charges) < 2 {
+		return Payment{}, false
+	}
+
+	period, ok := periodOf(charges)
+	if !ok {
+		return Payment{}, false
+	}
+
+	min := opts.MinOccurrences
+	if min <= 0 {
+		min = 3
+		if period == Annual {
+			min = 2
+		}
+	}
+	if len(charges) < min {
+		return Payment{}, false
+	}
+
+	first, last := charges[0], charges[len(charges)-1]
+	p := Payment{
+		Key:            key,
+		Merchant:       merchantName(last.Transaction),
+		Period:         period,
+		Currency:       last.Currency,
+		Count:          len(charges),
+		First:          first.created,
+		Last:           last.created,
+		Amount:         -last.Amount,
+		PreviousAmount: -charges[len(charges)-2].Amount,
+		NextDate:       period.next(last.created),
+		NextAmount:     -last.Amount,
+	}
+	for _, c := range charges {
+		p.TransactionIDs = append(p.TransactionIDs, c.ID)
+	}
+
+	return p, true
+}
+
+// periodOf returns the period the charges recur at. The median interval between charges must fit the period, and every other interval must fit a whole number of periods, so that a missed charge does not hide the payment.
+func periodOf(charges []charge) (Period, bool) {
+	var intervals []float64
+	for i := 1; i < len(charges); i++ {
+		intervals = append(intervals, charges[i].created.Sub(charges[i-1].created).Hours()/24)
+	}
+	sorted := append([]float64(nil), intervals...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		median = (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+	}
+
+	for _, candidate := range periods {
+		if math.Abs(median-candidate.nominal) > candidate.tolerance {
+			continue
+		}
+
+		fits := true
+		for _, days := range intervals {
+			n := math.Round(days / candidate.nominal)
+			if n < 1 || math.Abs(days-n*candidate.nominal) > candidate.tolerance {
+				fits = false
+				break
+			}
+		}
+		if fits {
+			return candidate.period, true
+		}
+	}
+	return "", false
+}
+
+// merchantKey groups transactions by merchant. Branches of the same merchant share a GroupID.
+func merchantKey(tx mondo.Transaction) string {
+	switch {
+	case tx.Merchant.GroupID != "":
+		return tx.Merchant.GroupID
+	case tx.Merchant.ID != "":
+		return tx.Merchant.ID
+	}
+	return "description:" + tx.Description
+}
+
+func merchantName(tx mondo.Transaction) string {
+	if tx.Merchant.Name != "" {
+		return tx.Merchant.Name
+	}
+	return tx.Description
+}
@@ -0,0 +1,143 @@
+package recurring
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/stretchr/testify/assert"
+)
+
+func charges(merchant mondo.Merchant, start time.Time, months, days int, amounts ...int) []mondo.Transaction {
+	var txs []mondo.Transaction
+	t := start
+	for i, amount := range amounts {
+		txs = append(txs, mondo.Transaction{
+			ID:       fmt.Sprintf("tx_%v_%v", merchant.GroupID, i),
+			Amount:   -amount,
+			Currency: "GBP",
+			Created:  t.Format(time.RFC3339),
+			Merchant: merchant,
+		})
+		t = t.AddDate(0, months, days)
+	}
+	return txs
+}
+
+func TestDetect(t *testing.T) {
+	netflix := mondo.Merchant{ID: "merch_netflix", GroupID: "grp_netflix", Name: "Netflix"}
+	gym := mondo.Merchant{ID: "merch_gym", GroupID: "grp_gym", Name: "Gym"}
+	domain := mondo.Merchant{ID: "merch_domain", GroupID: "grp_domain", Name: "Domain Registrar"}
+	coffee := mondo.Merchant{ID: "merch_coffee", GroupID: "grp_coffee", Name: "Coffee"}
+
+	start := time.Date(2015, 1, 15, 9, 0, 0, 0, time.UTC)
+
+	var txs []mondo.Transaction
+	txs = append(txs, charges(netflix, start, 1, 0, 599, 599, 599, 699)...)
+	txs = append(txs, charges(gym, start, 0, 7, 1000, 1000, 1000, 1000, 1000)...)
+	txs = append(txs, charges(domain, start, 12, 0, 1200, 1250)...)
+	txs = append(txs, charges(coffee, start, 0, 2, 250, 300, 250, 260)...)
+	txs = append(txs, mondo.Transaction{ID: "tx_topup", Amount: 10000, IsLoad: true, Created: start.Format(time.RFC3339)})
+
+	payments, err := Detect(txs, Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(payments))
+
+	gymPayment, netflixPayment, domainPayment := payments[0], payments[1], payments[2]
+
+	assert.Equal(t, "Gym", gymPayment.Merchant)
+	assert.Equal(t, Weekly, gymPayment.Period)
+	assert.Equal(t, 5, gymPayment.Count)
+	assert.False(t, gymPayment.PriceIncreased())
+
+	assert.Equal(t, "Netflix", netflixPayment.Merchant)
+	assert.Equal(t, Monthly, netflixPayment.Period)
+	assert.Equal(t, 699, netflixPayment.NextAmount)
+	assert.Equal(t, time.Date(2015, 5, 15, 9, 0, 0, 0, time.UTC), netflixPayment.NextDate)
+	assert.True(t, netflixPayment.PriceIncreased())
+	assert.Equal(t, 599, netflixPayment.PreviousAmount)
+	assert.Equal(t, []string{"tx_grp_netflix_0", "tx_grp_netflix_1", "tx_grp_netflix_2", "tx_grp_netflix_3"}, netflixPayment.TransactionIDs)
+
+	assert.Equal(t, Annual, domainPayment.Period)
+	assert.Equal(t, time.Date(2017, 1, 15, 9, 0, 0, 0, time.UTC), domainPayment.NextDate)
+
+	assert.True(t, netflixPayment.Active(time.Date(2015, 6, 1, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, netflixPayment.Active(time.Date(2015, 7, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestDetectAmountTolerance(t *testing.T) {
+	m := mondo.Merchant{GroupID: "grp_energy", Name: "Energy"}
+	txs := charges(m, time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC), 1, 0, 5000, 5000, 9000)
+
+	payments, err := Detect(txs, Options{})
+	assert.NoError(t, err)
+	assert.Empty(t, payments)
+
+	payments, err = Detect(txs, Options{AmountTolerance: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(payments))
+
+	payments, err = Detect(txs[:2], Options{MinOccurrences: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(payments))
+}
+
+func TestDetectAlongsideOneOffs(t *testing.T) {
+	apple := mondo.Merchant{ID: "merch_apple", GroupID: "grp_apple", Name: "Apple"}
+	start := time.Date(2015, 1, 15, 9, 0, 0, 0, time.UTC)
+
+	// A monthly subscription and a cheaper monthly storage plan, with a one-off purchase from the same merchant in between.
+	txs := charges(apple, start, 1, 0, 999, 999, 999, 999)
+	storage := charges(apple, start.AddDate(0, 0, 3), 1, 0, 79, 79, 79)
+	for i := range storage {
+		storage[i].ID = fmt.Sprintf("tx_storage_%v", i)
+	}
+	txs = append(txs, storage...)
+	txs = append(txs, mondo.Transaction{ID: "tx_one_off", Amount: -24900, Currency: "GBP", Created: start.AddDate(0, 1, 10).Format(time.RFC3339), Merchant: apple})
+
+	payments, err := Detect(txs, Options{})
+	assert.NoError(t, err)
+	if assert.Equal(t, 2, len(payments)) {
+		storagePayment, subscription := payments[0], payments[1]
+		assert.Equal(t, 79, storagePayment.Amount)
+		assert.Equal(t, 3, storagePayment.Count)
+		assert.Equal(t, Monthly, storagePayment.Period)
+
+		assert.Equal(t, "grp_apple", subscription.Key)
+		assert.Equal(t, Monthly, subscription.Period)
+		assert.Equal(t, 4, subscription.Count)
+		assert.Equal(t, 999, subscription.Amount)
+		assert.Equal(t, time.Date(2015, 5, 15, 9, 0, 0, 0, time.UTC), subscription.NextDate)
+		assert.NotContains(t, subscription.TransactionIDs, "tx_one_off")
+	}
+}
+
+func TestDetectMissedCharge(t *testing.T) {
+	gym := mondo.Merchant{ID: "merch_gym", GroupID: "grp_gym", Name: "Gym"}
+	start := time.Date(2015, 1, 15, 9, 0, 0, 0, time.UTC)
+
+	// March was skipped, leaving a 59 day gap, and May was charged two days late.
+	var txs []mondo.Transaction
+	for i, month := range []int{0, 1, 3, 4, 5} {
+		created := start.AddDate(0, month, 0)
+		if month == 4 {
+			created = created.AddDate(0, 0, 2)
+		}
+		txs = append(txs, mondo.Transaction{ID: fmt.Sprintf("tx_%v", i), Amount: -3000, Currency: "GBP", Created: created.Format(time.RFC3339), Merchant: gym})
+	}
+
+	payments, err := Detect(txs, Options{})
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(payments)) {
+		assert.Equal(t, Monthly, payments[0].Period)
+		assert.Equal(t, 5, payments[0].Count)
+		assert.Equal(t, time.Date(2015, 7, 15, 9, 0, 0, 0, time.UTC), payments[0].NextDate)
+	}
+
+	// Gaps that are not a whole number of periods are still not recurring.
+	txs[2].Created = start.AddDate(0, 2, 15).Format(time.RFC3339)
+	payments, err = Detect(txs, Options{})
+	assert.NoError(t, err)
+	assert.Empty(t, payments)
+}
@@ -0,0 +1,79 @@
+package mondo
+
+import (
+	"fmt"
+	"time"
+)
+
+var (
+	// The webhook event type synthesized when replaying transactions.
+	EventTransactionCreated = "transaction.created"
+
+	// Default number of transactions requested per page when replaying.
+	DefaultReplayPageSize = 100
+)
+
+// ReplayOptions controls which transactions ReplayTransactions pages through.
+type ReplayOptions struct {
+	// Since and Before bound the time window to replay. A zero value leaves that side of the window open.
+	Since  time.Time
+	Before time.Time
+
+	// PageSize is the limit passed to Transactions for each page. Defaults to DefaultReplayPageSize.
+	PageSize int
+
+	// Processed reports whether the caller has already handled the transaction with the given ID. Transactions for which it returns true are skipped. May be nil.
+	Processed func(transactionId string) bool
+}
+
+// ReplayTransactions pages through the transactions for an account within the window given in opts, and passes each one to handler as if it had been delivered as a transaction.created webhook. This allows consumers to backfill any events missed while their webhook receiver was unavailable. Replay stops at the first error returned by handler. It returns the number of events delivered to handler.
+func (m *MondoClient) ReplayTransactions(accountId string, opts ReplayOptions, handler func(*WebhookRequest) error) (int, error) {
+	if accountId == "" {
+		return 0, fmt.Errorf("accountId cannot be empty")
+	}
+
+	if handler == nil {
+		return 0, fmt.Errorf("handler cannot be nil")
+	}
+
+	limit := opts.PageSize
+	if limit <= 0 {
+		limit = DefaultReplayPageSize
+	}
+
+	var since, before string
+	if !opts.Since.IsZero() {
+		since = opts.Since.Format(time.RFC3339)
+	}
+	if !opts.Before.IsZero() {
+		before = opts.Before.Format(time.RFC3339)
+	}
+
+	delivered := 0
+	for {
+		transactions, err := m.Transactions(accountId, since, before, limit)
+		if err != nil {
+			return delivered, err
+		}
+
+		for i := range transactions {
+			tx := transactions[i]
+			if opts.Processed != nil && opts.Processed(tx.ID) {
+				continue
+			}
+
+			if err := handler(&WebhookRequest{Type: EventTransactionCreated, Data: &tx}); err != nil {
+				return delivered, fmt.Errorf("replaying %v: %v", tx.ID, err)
+			}
+			delivered++
+		}
+
+		// A short page means we have reached the end of the window.
+		if len(transactions) < limit {
+			return delivered, nil
+		}
+
+		// Paginate by passing the last transaction ID we saw as since.
+		since = transactions[len(transactions)-1].ID
+	}
+}
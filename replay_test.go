@@ -0,0 +1,82 @@
+package mondo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayTransactions(t *testing.T) {
+	setup()
+	defer teardown()
+
+	ids := []string{"tx_1", "tx_2", "tx_3", "tx_4", "tx_5"}
+	var sinces []string
+
+	mux.HandleFunc("/transactions",
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "GET", r.Method)
+			assert.Equal(t, "2015-08-01T00:00:00Z", r.FormValue("before"))
+			sinces = append(sinces, r.FormValue("since"))
+
+			limit, _ := strconv.Atoi(r.FormValue("limit"))
+			start := 0
+			for i, id := range ids {
+				if id == r.FormValue("since") {
+					start = i + 1
+				}
+			}
+			end := start + limit
+			if end > len(ids) {
+				end = len(ids)
+			}
+
+			var page []Transaction
+			for _, id := range ids[start:end] {
+				page = append(page, Transaction{ID: id})
+			}
+			b, _ := json.Marshal(map[string][]Transaction{"transactions": page})
+			w.Write(b)
+		},
+	)
+
+	client, err := Authenticate("some", "valid", "credentials", "here")
+	assert.NoError(t, err)
+
+	var seen []string
+	n, err := client.ReplayTransactions("account1", ReplayOptions{
+		Since:     time.Date(2015, 7, 1, 0, 0, 0, 0, time.UTC),
+		Before:    time.Date(2015, 8, 1, 0, 0, 0, 0, time.UTC),
+		PageSize:  2,
+		Processed: func(id string) bool { return id == "tx_2" },
+	}, func(ev *WebhookRequest) error {
+		assert.Equal(t, EventTransactionCreated, ev.Type)
+		seen = append(seen, ev.Data.ID)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, n)
+	assert.Equal(t, []string{"tx_1", "tx_3", "tx_4", "tx_5"}, seen)
+	assert.Equal(t, []string{"2015-07-01T00:00:00Z", "tx_2", "tx_4"}, sinces)
+
+	// Handler errors stop the replay.
+	n, err = client.ReplayTransactions("account1", ReplayOptions{
+		Before:   time.Date(2015, 8, 1, 0, 0, 0, 0, time.UTC),
+		PageSize: 2,
+	}, func(ev *WebhookRequest) error {
+		if ev.Data.ID == "tx_3" {
+			return fmt.Errorf("boom")
+		}
+		return nil
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 2, n)
+
+	_, err = client.ReplayTransactions("", ReplayOptions{}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,205 @@
+// Package report summarises Mondo transactions by category, merchant or period.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sjwhitworth/gomondo"
+)
+
+// Grouping is how transactions are grouped into the rows of a Summary.
+type Grouping string
+
+const (
+	ByCategory Grouping = "category"
+	ByMerchant Grouping = "merchant"
+	ByDay      Grouping = "day"
+	ByWeek     Grouping = "week"
+	ByMonth    Grouping = "month"
+)
+
+// ParseGrouping parses the name of a Grouping, such as "merchant".
+func ParseGrouping(s string) (Grouping, error) {
+	switch g := Grouping(s); g {
+	case ByCategory, ByMerchant, ByDay, ByWeek, ByMonth:
+		return g, nil
+	}
+	return "", fmt.Errorf("unknown grouping %q: use category, merchant, day, week or month", s)
+}
+
+// Totals splits the money moving through an account into spending, income and top ups. All amounts are positive, in minor units such as pence.
+type Totals struct {
+	// Count is the number of transactions.
+	Count int `json:"count"`
+
+	// Spend is money leaving the account.
+	Spend      int `json:"spend"`
+	SpendCount int `json:"spend_count"`
+
+	// Income is money arriving in the account other than top ups, such as refunds.
+	Income int `json:"income"`
+
+	// TopUps is money loaded onto the account, which is neither income nor spending.
+	TopUps int `json:"top_ups"`
+}
+
+// AverageSpend returns the mean amount of each spending transaction.
+func (t Totals) AverageSpend() int {
+	if t.SpendCount == 0 {
+		return 0
+	}
+	return t.Spend / t.SpendCount
+}
+
+// Net returns income and top ups less spending.
+func (t Totals) Net() int {
+	return t.Income + t.TopUps - t.Spend
+}
+
+func (t *Totals) add(tx mondo.Transaction) {
+	t.Count++
+	switch {
+	case tx.IsLoad:
+		t.TopUps += tx.Amount
+	case tx.Amount < 0:
+		t.Spend -= tx.Amount
+		t.SpendCount++
+	default:
+		t.Income += tx.Amount
+	}
+}
+
+// Row is the totals for one group of transactions.
+type Row struct {
+	// Key identifies the group, such as a category, merchant group ID or period like 2015-08.
+	Key string `json:"key"`
+
+	// Label is a human readable name for the group.
+	Label string `json:"label"`
+
+	Totals
+
+	// SpendShare is the percentage of all spending that happened in this group.
+	SpendShare float64 `json:"spend_share"`
+}
+
+// MarshalJSON adds the result of AverageSpend to the fields of the row, as average_spend.
+func (r Row) MarshalJSON() ([]byte, error) {
+	type plain Row
+	return json.Marshal(struct {
+		plain
+		AverageSpend int `json:"average_spend"`
+	}{plain(r), r.AverageSpend()})
+}
+
+// Summary is the result of summarising a set of transactions.
+type Summary struct {
+	Grouping Grouping `json:"grouping"`
+	Currency string   `json:"currency"`
+	Rows     []Row    `json:"rows"`
+	Total    Totals   `json:"total"`
+}
+
+// Summarise groups transactions and totals each group. Rows grouped by period are in chronological order. Other rows are ordered by how much was spent in them, largest first. The transactions must all be in the same currency, as amounts in different currencies cannot be added together.
+func Summarise(transactions []mondo.Transaction, by Grouping) (*Summary, error) {
+	s := &Summary{Grouping: by}
+
+	rows := map[string]*Row{}
+	var keys []string
+	for _, tx := range transactions {
+		switch {
+		case tx.Currency == "":
+		case s.Currency == "":
+			s.Currency = tx.Currency
+		case tx.Currency != s.Currency:
+			return nil, fmt.Errorf("transactions are in more than one currency: %v and %v", s.Currency, tx.Currency)
+		}
+
+		key, label, err := group(tx, by)
+		if err != nil {
+			return nil, err
+		}
+
+		row, ok := rows[key]
+		if !ok {
+			row = &Row{Key: key, Label: label}
+			rows[key] = row
+			keys = append(keys, key)
+		}
+
+		row.add(tx)
+		s.Total.add(tx)
+	}
+
+	for _, key := range keys {
+		row := rows[key]
+		if s.Total.Spend > 0 {
+			row.SpendShare = 100 * float64(row.Spend) / float64(s.Total.Spend)
+		}
+		s.Rows = append(s.Rows, *row)
+	}
+
+	switch by {
+	case ByDay, ByWeek, ByMonth:
+		sort.Slice(s.Rows, func(i, j int) bool { return s.Rows[i].Key < s.Rows[j].Key })
+	default:
+		sort.SliceStable(s.Rows, func(i, j int) bool {
+			if s.Rows[i].Spend != s.Rows[j].Spend {
+				return s.Rows[i].Spend > s.Rows[j].Spend
+			}
+			return s.Rows[i].Key < s.Rows[j].Key
+		})
+	}
+
+	return s, nil
+}
+
+// group returns the key and label of the group tx belongs to.
+func group(tx mondo.Transaction, by Grouping) (string, string, error) {
+	switch by {
+	case ByCategory:
+		return tx.Category, tx.Category, nil
+	case ByMerchant:
+		return merchant(tx)
+	}
+
+	created, err := time.Parse(time.RFC3339, tx.Created)
+	if err != nil {
+		return "", "", fmt.Errorf("transaction %v has invalid created time: %v", tx.ID, err)
+	}
+
+	var key string
+	switch by {
+	case ByDay:
+		key = created.Format("2006-01-02")
+	case ByWeek:
+		year, week := created.ISOWeek()
+		key = fmt.Sprintf("%04d-W%02d", year, week)
+	case ByMonth:
+		key = created.Format("2006-01")
+	default:
+		return "", "", fmt.Errorf("unknown grouping %q", by)
+	}
+	return key, key, nil
+}
+
+// merchant returns the key and label for the merchant of tx. Branches of the same merchant share a GroupID, so they are merged together.
+func merchant(tx mondo.Transaction) (string, string, error) {
+	if tx.Category == "mondo" || tx.IsLoad {
+		return "mondo", "Mondo", nil
+	}
+
+	m := tx.Merchant
+	switch {
+	case m.GroupID != "":
+		return m.GroupID, m.Name, nil
+	case m.ID != "":
+		return m.ID, m.Name, nil
+	}
+
+	// Transactions without a merchant, such as transfers, are grouped by their description.
+	return "description:" + tx.Description, tx.Description, nil
+}
@@ -0,0 +1,94 @@
+package report
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/stretchr/testify/assert"
+)
+
+var transactions = []mondo.Transaction{
+	{ID: "tx_1", Amount: 10000, Created: "2015-08-03T09:00:00Z", Currency: "GBP", Category: "mondo", IsLoad: true},
+	{ID: "tx_2", Amount: -510, Created: "2015-08-03T12:20:18Z", Currency: "GBP", Category: "eating_out",
+		Merchant: mondo.Merchant{ID: "merch_1", GroupID: "grp_deli", Name: "The De Beauvoir Deli Co."}},
+	{ID: "tx_3", Amount: -690, Created: "2015-08-10T12:00:00Z", Currency: "GBP", Category: "eating_out",
+		Merchant: mondo.Merchant{ID: "merch_2", GroupID: "grp_deli", Name: "The De Beauvoir Deli Co. Islington"}},
+	{ID: "tx_4", Amount: -2800, Created: "2015-09-01T18:00:00Z", Currency: "GBP", Category: "groceries",
+		Merchant: mondo.Merchant{ID: "merch_3", GroupID: "grp_tesco", Name: "Tesco"}},
+	{ID: "tx_5", Amount: 510, Created: "2015-09-02T10:00:00Z", Currency: "GBP", Category: "eating_out",
+		Merchant: mondo.Merchant{ID: "merch_1", GroupID: "grp_deli", Name: "The De Beauvoir Deli Co."}},
+}
+
+func TestSummariseByCategory(t *testing.T) {
+	s, err := Summarise(transactions, ByCategory)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "GBP", s.Currency)
+	assert.Equal(t, Totals{Count: 5, Spend: 4000, SpendCount: 3, Income: 510, TopUps: 10000}, s.Total)
+	assert.Equal(t, 6510, s.Total.Net())
+
+	assert.Equal(t, 3, len(s.Rows))
+	assert.Equal(t, "groceries", s.Rows[0].Key)
+	assert.Equal(t, 70.0, s.Rows[0].SpendShare)
+
+	eatingOut := s.Rows[1]
+	assert.Equal(t, "eating_out", eatingOut.Key)
+	assert.Equal(t, 1200, eatingOut.Spend)
+	assert.Equal(t, 510, eatingOut.Income)
+	assert.Equal(t, 600, eatingOut.AverageSpend())
+	assert.Equal(t, 30.0, eatingOut.SpendShare)
+
+	assert.Equal(t, "mondo", s.Rows[2].Key)
+	assert.Equal(t, 10000, s.Rows[2].TopUps)
+	assert.Equal(t, 0, s.Rows[2].Spend)
+}
+
+func TestSummariseByMerchant(t *testing.T) {
+	s, err := Summarise(transactions, ByMerchant)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"grp_tesco", "grp_deli", "mondo"}, []string{s.Rows[0].Key, s.Rows[1].Key, s.Rows[2].Key})
+	assert.Equal(t, "The De Beauvoir Deli Co.", s.Rows[1].Label)
+	assert.Equal(t, 3, s.Rows[1].Count)
+}
+
+func TestSummariseByPeriod(t *testing.T) {
+	s, err := Summarise(transactions, ByMonth)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(s.Rows))
+	assert.Equal(t, "2015-08", s.Rows[0].Key)
+	assert.Equal(t, 1200, s.Rows[0].Spend)
+	assert.Equal(t, "2015-09", s.Rows[1].Key)
+
+	s, err = Summarise(transactions, ByWeek)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2015-W32", "2015-W33", "2015-W36"}, []string{s.Rows[0].Key, s.Rows[1].Key, s.Rows[2].Key})
+
+	s, err = Summarise(transactions, ByDay)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(s.Rows))
+
+	_, err = Summarise([]mondo.Transaction{{ID: "tx_bad", Created: "yesterday"}}, ByDay)
+	assert.Error(t, err)
+
+	_, err = ParseGrouping("fortnight")
+	assert.Error(t, err)
+}
+
+func TestSummariseJSON(t *testing.T) {
+	s, err := Summarise(transactions, ByCategory)
+	assert.NoError(t, err)
+
+	b, err := json.Marshal(s.Rows[1])
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"key": "eating_out", "label": "eating_out", "count": 3, "spend": 1200, "spend_count": 2, "income": 510, "top_ups": 0, "spend_share": 30, "average_spend": 600}`, string(b))
+}
+
+func TestSummariseMixedCurrencies(t *testing.T) {
+	mixed := append([]mondo.Transaction{}, transactions...)
+	mixed = append(mixed, mondo.Transaction{ID: "tx_6", Amount: -1000, Created: "2015-09-03T10:00:00Z", Currency: "EUR", Category: "eating_out"})
+
+	_, err := Summarise(mixed, ByCategory)
+	assert.EqualError(t, err, "transactions are in more than one currency: GBP and EUR")
+}
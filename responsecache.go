@@ -0,0 +1,319 @@
+package mondo
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResponseCache stores responses to GET requests, so that a MondoClient with one set can answer repeated reads without calling the API while they are fresh, and revalidate them with conditional requests once they are not. Responses are cached as the API allows with its ETag, Last-Modified and Cache-Control headers. Implementations must be safe for concurrent use, and must not modify the responses they are given.
+type ResponseCache interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, resp *CachedResponse)
+	Delete(key string)
+}
+
+// CachedResponse is a response stored in a ResponseCache.
+type CachedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+
+	// Stored is when the response was received, or last revalidated.
+	Stored time.Time `json:"stored"`
+}
+
+// The values of AttributeCache, recording how a GET request was answered when the client has a ResponseCache.
+var (
+	CacheHit         = "hit"
+	CacheRevalidated = "revalidated"
+	CacheMiss        = "miss"
+)
+
+// AttributeCache is the span attribute recording how a GET request was answered when the client has a ResponseCache.
+var AttributeCache = "mondo.cache"
+
+// WithoutCache returns a copy of the client whose calls skip the ResponseCache, and always fetch a fresh response from the API. Fresh responses are still stored for later calls.
+func (m *MondoClient) WithoutCache() *MondoClient {
+	c := *m
+	c.bypassCache = true
+	return &c
+}
+
+// cachedGet makes a GET request through the ResponseCache.
+func (m *MondoClient) cachedGet(c *call, URL string, params map[string]string) (*http.Response, error) {
+	req, err := newRequest("GET", URL, params)
+	if err != nil {
+		return nil, err
+	}
+	key := m.auth.user() + " " + req.URL.String()
+
+	var entry *CachedResponse
+	var ok bool
+	if !m.bypassCache {
+		entry, ok = m.ResponseCache.Get(key)
+	}
+
+	if ok && entry.fresh(time.Now(), m.auth.written()) {
+		c.setAttribute(AttributeCache, CacheHit)
+		if m.Logger != nil {
+			m.Logger.Debug("mondo: request answered from cache", "method", "GET", "path", redact(req.URL.RequestURI()))
+		}
+		return entry.response(req), nil
+	}
+
+	header := http.Header{}
+	if ok {
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			header.Set("If-None-Match", etag)
+		}
+		if modified := entry.Header.Get("Last-Modified"); modified != "" {
+			header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	resp, err := m.send(c, "GET", URL, params, header)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		entry = entry.revalidate(resp.Header, time.Now())
+		m.ResponseCache.Set(key, entry)
+		c.setAttribute(AttributeCache, CacheRevalidated)
+		return entry.response(req), nil
+	}
+
+	c.setAttribute(AttributeCache, CacheMiss)
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+	if !storable(resp.Header) {
+		m.ResponseCache.Delete(key)
+		return resp, nil
+	}
+
+	// The body has to be read in full to be stored.
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	m.ResponseCache.Set(key, &CachedResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+		Stored:     time.Now(),
+	})
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// response returns a fresh http.Response with the stored status, headers and body.
+func (r *CachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        strconv.Itoa(r.StatusCode) + " " + http.StatusText(r.StatusCode),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.Header,
+		Body:          ioutil.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+// fresh reports whether the response can be used without revalidating it: its max-age has not passed, and the client has not changed anything since it was stored.
+func (r *CachedResponse) fresh(now, lastWrite time.Time) bool {
+	if !r.Stored.After(lastWrite) {
+		return false
+	}
+
+	directives := cacheControl(r.Header)
+	if _, ok := directives["no-cache"]; ok {
+		return false
+	}
+	maxAge, err := strconv.Atoi(directives["max-age"])
+	if err != nil {
+		return false
+	}
+	return now.Sub(r.Stored) < time.Duration(maxAge)*time.Second
+}
+
+// revalidate returns a copy of the response updated by the headers of a 304 Not Modified response to a conditional request.
+func (r *CachedResponse) revalidate(header http.Header, now time.Time) *CachedResponse {
+	updated := *r
+	updated.Header = r.Header.Clone()
+	for _, k := range []string{"Cache-Control", "Date", "ETag", "Expires", "Last-Modified"} {
+		if v, ok := header[k]; ok {
+			updated.Header[k] = v
+		}
+	}
+	updated.Stored = now
+	return &updated
+}
+
+// storable reports whether a response may be cached: the API must allow it, and give a way to either reuse or revalidate it.
+func storable(header http.Header) bool {
+	directives := cacheControl(header)
+	if _, ok := directives["no-store"]; ok {
+		return false
+	}
+	_, maxAge := directives["max-age"]
+	return maxAge || header.Get("ETag") != "" || header.Get("Last-Modified") != ""
+}
+
+// cacheControl parses a Cache-Control header into its directives and their values.
+func cacheControl(header http.Header) map[string]string {
+	directives := map[string]string{}
+	for _, part := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value := strings.TrimSpace(part), ""
+		if i := strings.Index(name, "="); i >= 0 {
+			name, value = name[:i], strings.Trim(name[i+1:], `"`)
+		}
+		if name != "" {
+			directives[strings.ToLower(name)] = value
+		}
+	}
+	return directives
+}
+
+// MemoryCache is a ResponseCache held in memory, which evicts the least recently used response once it holds its limit. It is safe for concurrent use.
+type MemoryCache struct {
+	size int
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key  string
+	resp *CachedResponse
+}
+
+// NewMemoryCache returns a MemoryCache that holds at most size responses.
+func NewMemoryCache(size int) *MemoryCache {
+	if size < 1 {
+		size = 1
+	}
+	return &MemoryCache{size: size, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+func (c *MemoryCache) Get(key string) (*CachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*memoryEntry).resp, true
+}
+
+func (c *MemoryCache) Set(key string, resp *CachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*memoryEntry).resp = resp
+		c.order.MoveToFront(e)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&memoryEntry{key, resp})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryEntry).key)
+	}
+}
+
+func (c *MemoryCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		c.order.Remove(e)
+		delete(c.entries, key)
+	}
+}
+
+// Len returns the number of responses held.
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// DiskCache is a ResponseCache that stores each response as a JSON file in a directory, so that it survives restarts. Files are written atomically, so it is safe for concurrent use, including by several processes. A response that cannot be written is simply not cached.
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache returns a DiskCache storing responses in dir, which is created if need be.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+// path returns the file a response is stored in. Keys are hashed, as they hold URLs and user IDs.
+func (c *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *DiskCache) Get(key string) (*CachedResponse, bool) {
+	b, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var resp CachedResponse
+	if err := json.Unmarshal(b, &resp); err != nil {
+		return nil, false
+	}
+	return &resp, true
+}
+
+func (c *DiskCache) Set(key string, resp *CachedResponse) {
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+
+	tmp, err := ioutil.TempFile(c.dir, ".response")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(b)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path(key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+func (c *DiskCache) Delete(key string) {
+	os.Remove(c.path(key))
+}
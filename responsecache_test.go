@@ -0,0 +1,215 @@
+package mondo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// versionedAccounts serves /accounts with an ETag for the current version, and records how each request was answered.
+type versionedAccounts struct {
+	mu           sync.Mutex
+	version      int
+	cacheControl string
+	statuses     []int
+}
+
+func (v *versionedAccounts) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	etag := fmt.Sprintf(`"v%v"`, v.version)
+	w.Header().Set("ETag", etag)
+	if v.cacheControl != "" {
+		w.Header().Set("Cache-Control", v.cacheControl)
+	}
+
+	if r.Header.Get("If-None-Match") == etag {
+		v.statuses = append(v.statuses, 304)
+		w.WriteHeader(304)
+		return
+	}
+	v.statuses = append(v.statuses, 200)
+	fmt.Fprintf(w, `{"accounts": [{"id": "acc_%v"}]}`, v.version)
+}
+
+func (v *versionedAccounts) answered() []int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	statuses := v.statuses
+	v.statuses = nil
+	return statuses
+}
+
+func accountID(t *testing.T, client *MondoClient) string {
+	acs, err := client.Accounts()
+	assert.NoError(t, err)
+	if assert.Len(t, acs, 1) {
+		return acs[0].ID
+	}
+	return ""
+}
+
+func TestResponseCacheRevalidates(t *testing.T) {
+	setup()
+	defer teardown()
+
+	v := &versionedAccounts{version: 1}
+	mux.Handle("/accounts", v)
+
+	client, err := Authenticate("some", "valid", "credentials", "here")
+	assert.NoError(t, err)
+	client.ResponseCache = NewMemoryCache(10)
+	tracer := &recordingTracer{}
+	client.Tracer = tracer
+
+	assert.Equal(t, "acc_1", accountID(t, client))
+	assert.Equal(t, []int{200}, v.answered())
+
+	// Without a max-age, the response is revalidated each time, and only downloaded again once it changes.
+	assert.Equal(t, "acc_1", accountID(t, client))
+	assert.Equal(t, []int{304}, v.answered())
+
+	v.version = 2
+	assert.Equal(t, "acc_2", accountID(t, client))
+	assert.Equal(t, []int{200}, v.answered())
+
+	var outcomes []interface{}
+	for _, s := range tracer.spans {
+		outcomes = append(outcomes, s.attributes[AttributeCache])
+	}
+	assert.Equal(t, []interface{}{CacheMiss, CacheRevalidated, CacheMiss}, outcomes)
+}
+
+func TestResponseCacheMaxAge(t *testing.T) {
+	setup()
+	defer teardown()
+
+	v := &versionedAccounts{version: 1, cacheControl: "private, max-age=60"}
+	mux.Handle("/accounts", v)
+	mux.HandleFunc("/transactions/tx_1",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"transaction": {"id": "tx_1"}}`)
+		},
+	)
+
+	client, err := Authenticate("some", "valid", "credentials", "here")
+	assert.NoError(t, err)
+	client.ResponseCache = NewMemoryCache(10)
+
+	assert.Equal(t, "acc_1", accountID(t, client))
+	assert.Equal(t, []int{200}, v.answered())
+
+	// A fresh response is used without calling the API.
+	v.version = 2
+	assert.Equal(t, "acc_1", accountID(t, client))
+	assert.Empty(t, v.answered())
+
+	// Bypassing the cache fetches, and stores, a fresh response.
+	assert.Equal(t, "acc_2", accountID(t, client.WithoutCache()))
+	assert.Equal(t, []int{200}, v.answered())
+	assert.Equal(t, "acc_2", accountID(t, client))
+	assert.Empty(t, v.answered())
+
+	// Changing anything makes every response stored before it stale.
+	_, err = client.AnnotateTransaction("tx_1", map[string]string{"k": "v"})
+	assert.NoError(t, err)
+	assert.Equal(t, "acc_2", accountID(t, client))
+	assert.Equal(t, []int{304}, v.answered())
+	assert.Equal(t, "acc_2", accountID(t, client))
+	assert.Empty(t, v.answered())
+}
+
+func TestResponseCacheNoStore(t *testing.T) {
+	setup()
+	defer teardown()
+
+	v := &versionedAccounts{version: 1, cacheControl: "no-store"}
+	mux.Handle("/accounts", v)
+
+	client, err := Authenticate("some", "valid", "credentials", "here")
+	assert.NoError(t, err)
+	cache := NewMemoryCache(10)
+	client.ResponseCache = cache
+
+	accountID(t, client)
+	accountID(t, client)
+	assert.Equal(t, []int{200, 200}, v.answered())
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestCacheControl(t *testing.T) {
+	header := http.Header{"Cache-Control": {`private, max-age="30", No-Cache`}}
+	assert.Equal(t, map[string]string{"private": "", "max-age": "30", "no-cache": ""}, cacheControl(header))
+
+	now := time.Now()
+	stored := &CachedResponse{Header: http.Header{"Cache-Control": {"max-age=30"}}, Stored: now.Add(-10 * time.Second)}
+	assert.True(t, stored.fresh(now, time.Time{}))
+	assert.False(t, stored.fresh(now.Add(time.Minute), time.Time{}))
+	assert.False(t, stored.fresh(now, now.Add(-5*time.Second)))
+
+	assert.True(t, storable(http.Header{"Etag": {`"v1"`}}))
+	assert.True(t, storable(http.Header{"Last-Modified": {"Mon, 24 Aug 2015 12:00:00 GMT"}}))
+	assert.False(t, storable(http.Header{}))
+	assert.False(t, storable(http.Header{"Etag": {`"v1"`}, "Cache-Control": {"no-store"}}))
+}
+
+func TestMemoryCache(t *testing.T) {
+	c := NewMemoryCache(2)
+	c.Set("a", &CachedResponse{Body: []byte("a")})
+	c.Set("b", &CachedResponse{Body: []byte("b")})
+
+	// Reading a makes b the least recently used.
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+	c.Set("c", &CachedResponse{Body: []byte("c")})
+
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+	resp, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "a", string(resp.Body))
+	assert.Equal(t, 2, c.Len())
+
+	c.Delete("a")
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestDiskCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "responses")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c, err := NewDiskCache(dir)
+	assert.NoError(t, err)
+
+	stored := &CachedResponse{
+		StatusCode: 200,
+		Header:     http.Header{"Etag": {`"v1"`}},
+		Body:       []byte(`{"accounts": []}`),
+		Stored:     time.Date(2015, 8, 24, 12, 0, 0, 0, time.UTC),
+	}
+	c.Set("user_1 /accounts", stored)
+
+	// Responses survive the cache being opened again.
+	c, err = NewDiskCache(dir)
+	assert.NoError(t, err)
+	resp, ok := c.Get("user_1 /accounts")
+	assert.True(t, ok)
+	assert.Equal(t, stored, resp)
+
+	_, ok = c.Get("user_2 /accounts")
+	assert.False(t, ok)
+
+	c.Delete("user_1 /accounts")
+	_, ok = c.Get("user_1 /accounts")
+	assert.False(t, ok)
+}
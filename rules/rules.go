@@ -0,0 +1,297 @@
+// Package rules tags Mondo transactions automatically, using declarative rules that set categories, notes and metadata on the transactions they match.
+//
+// Rules are usually loaded from a JSON file:
+//
+//	{
+//	  "rules": [
+//	    {
+//	      "name": "coffee",
+//	      "match": {"merchant": "(?i)pret|costa", "max_amount": 500},
+//	      "set": {"category": "coffee", "metadata": {"habit": "caffeine"}}
+//	    },
+//	    {
+//	      "name": "big online purchases",
+//	      "match": {"direction": "debit", "online": true, "min_amount": 10000},
+//	      "set": {"notes": "Check the receipt"},
+//	      "stop": true
+//	    }
+//	  ]
+//	}
+//
+// Rules are tried in order and every rule that matches is applied, so later rules override earlier ones, unless a matching rule sets stop. Changes are written back to Mondo as transaction annotations. Mondo does not let API clients recategorise a transaction, so the category a rule sets is stored in the transaction's metadata under CategoryKey.
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+
+	"github.com/sjwhitworth/gomondo"
+)
+
+var (
+	// The metadata key that categories set by rules are stored under.
+	CategoryKey = "category"
+
+	// The metadata key that holds a transaction's notes.
+	NotesKey = "notes"
+)
+
+// Ruleset is an ordered list of rules.
+type Ruleset struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rule sets fields on the transactions it matches.
+type Rule struct {
+	Name  string `json:"name"`
+	Match Match  `json:"match"`
+	Set   Set    `json:"set"`
+
+	// Stop prevents any later rule from being applied to a transaction this rule matches.
+	Stop bool `json:"stop,omitempty"`
+}
+
+// Match is the condition a transaction must meet for a rule to apply. Every field that is set must match; an empty Match matches every transaction. Description, Merchant and MerchantCategory are regular expressions.
+type Match struct {
+	Description      string `json:"description,omitempty"`
+	Merchant         string `json:"merchant,omitempty"`
+	MerchantCategory string `json:"merchant_category,omitempty"`
+
+	// Category is the Mondo category of the transaction, such as eating_out.
+	Category string `json:"category,omitempty"`
+
+	// Direction is debit for money leaving the account, or credit for money arriving.
+	Direction string `json:"direction,omitempty"`
+
+	// MinAmount and MaxAmount bound the size of the transaction, in minor units such as pence, regardless of its direction. Both are inclusive.
+	MinAmount *int `json:"min_amount,omitempty"`
+	MaxAmount *int `json:"max_amount,omitempty"`
+
+	Online *bool `json:"online,omitempty"`
+	IsLoad *bool `json:"is_load,omitempty"`
+
+	description, merchant, merchantCategory *regexp.Regexp
+}
+
+// Set is what a rule changes on the transactions it matches.
+type Set struct {
+	Category string            `json:"category,omitempty"`
+	Notes    string            `json:"notes,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Change is the metadata that applying a Ruleset would change on one transaction.
+type Change struct {
+	TransactionID string `json:"transaction_id"`
+	Description   string `json:"description"`
+
+	// Rules names the rules that matched, in the order they were applied.
+	Rules []string `json:"rules"`
+
+	// Metadata holds only the keys whose values differ from the transaction's current ones.
+	Metadata map[string]string `json:"metadata"`
+}
+
+// Keys returns the keys of the change's metadata in order.
+func (c Change) Keys() []string {
+	var keys []string
+	for k := range c.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// LoadRules reads a Ruleset from a JSON file.
+func LoadRules(path string) (*Ruleset, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseRules(f)
+}
+
+// ParseRules reads a Ruleset from JSON and checks it.
+func ParseRules(r io.Reader) (*Ruleset, error) {
+	rs := &Ruleset{}
+	if err := json.NewDecoder(r).Decode(rs); err != nil {
+		return nil, fmt.Errorf("invalid rules: %v", err)
+	}
+
+	for i := range rs.Rules {
+		rule := &rs.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %v", i+1)
+		}
+
+		if err := rule.Match.compile(); err != nil {
+			return nil, fmt.Errorf("%v: %v", rule.Name, err)
+		}
+
+		if rule.Set.Category == "" && rule.Set.Notes == "" && len(rule.Set.Metadata) == 0 {
+			return nil, fmt.Errorf("%v sets nothing", rule.Name)
+		}
+	}
+
+	return rs, nil
+}
+
+func (m *Match) compile() error {
+	var err error
+	if m.description, err = compile("description", m.Description); err != nil {
+		return err
+	}
+	if m.merchant, err = compile("merchant", m.Merchant); err != nil {
+		return err
+	}
+	if m.merchantCategory, err = compile("merchant_category", m.MerchantCategory); err != nil {
+		return err
+	}
+
+	switch m.Direction {
+	case "", "debit", "credit":
+	default:
+		return fmt.Errorf("invalid direction %q: use debit or credit", m.Direction)
+	}
+
+	return nil
+}
+
+func compile(field, expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v pattern %q: %v", field, expr, err)
+	}
+	return re, nil
+}
+
+// Matches reports whether tx meets every condition of m.
+func (m *Match) Matches(tx mondo.Transaction) bool {
+	if m.description != nil && !m.description.MatchString(tx.Description) {
+		return false
+	}
+	if m.merchant != nil && !m.merchant.MatchString(tx.Merchant.Name) {
+		return false
+	}
+	if m.merchantCategory != nil && !m.merchantCategory.MatchString(tx.Merchant.Category) {
+		return false
+	}
+	if m.Category != "" && m.Category != tx.Category {
+		return false
+	}
+
+	switch m.Direction {
+	case "debit":
+		if tx.Amount >= 0 {
+			return false
+		}
+	case "credit":
+		if tx.Amount <= 0 {
+			return false
+		}
+	}
+
+	amount := tx.Amount
+	if amount < 0 {
+		amount = -amount
+	}
+	if m.MinAmount != nil && amount < *m.MinAmount {
+		return false
+	}
+	if m.MaxAmount != nil && amount > *m.MaxAmount {
+		return false
+	}
+
+	if m.Online != nil && *m.Online != tx.Merchant.Online {
+		return false
+	}
+	if m.IsLoad != nil && *m.IsLoad != tx.IsLoad {
+		return false
+	}
+
+	return true
+}
+
+// Evaluate returns the change the rules make to tx, or nil if they change nothing.
+func (rs *Ruleset) Evaluate(tx mondo.Transaction) *Change {
+	var names []string
+	want := map[string]string{}
+	for _, rule := range rs.Rules {
+		if !rule.Match.Matches(tx) {
+			continue
+		}
+
+		names = append(names, rule.Name)
+		for k, v := range rule.Set.Metadata {
+			want[k] = v
+		}
+		if rule.Set.Category != "" {
+			want[CategoryKey] = rule.Set.Category
+		}
+		if rule.Set.Notes != "" {
+			want[NotesKey] = rule.Set.Notes
+		}
+
+		if rule.Stop {
+			break
+		}
+	}
+
+	changed := map[string]string{}
+	for k, v := range want {
+		if current(tx, k) != v {
+			changed[k] = v
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	return &Change{
+		TransactionID: tx.ID,
+		Description:   tx.Description,
+		Rules:         names,
+		Metadata:      changed,
+	}
+}
+
+// current returns the value a transaction has for a metadata key.
+func current(tx mondo.Transaction, key string) string {
+	if key == NotesKey && tx.Notes != "" {
+		return tx.Notes
+	}
+	if v, ok := tx.Metadata[key]; ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// Plan returns the changes the rules make to transactions, in the same order. Transactions they leave alone are skipped.
+func (rs *Ruleset) Plan(transactions []mondo.Transaction) []Change {
+	var changes []Change
+	for _, tx := range transactions {
+		if c := rs.Evaluate(tx); c != nil {
+			changes = append(changes, *c)
+		}
+	}
+	return changes
+}
+
+// Apply writes changes back to Mondo by annotating each transaction. It stops at the first failure, returning how many changes were applied before it.
+func Apply(client mondo.Client, changes []Change) (int, error) {
+	for i, c := range changes {
+		if _, err := client.AnnotateTransaction(c.TransactionID, c.Metadata); err != nil {
+			return i, fmt.Errorf("failed to annotate %v: %v", c.TransactionID, err)
+		}
+	}
+	return len(changes), nil
+}
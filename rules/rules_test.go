@@ -0,0 +1,101 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sjwhitworth/gomondo"
+	"github.com/sjwhitworth/gomondo/mondotest"
+	"github.com/stretchr/testify/assert"
+)
+
+const ruleset = `{
+  "rules": [
+    {
+      "name": "coffee",
+      "match": {"merchant": "(?i)pret|costa", "max_amount": 500},
+      "set": {"category": "coffee", "metadata": {"habit": "caffeine"}}
+    },
+    {
+      "name": "big online purchases",
+      "match": {"direction": "debit", "online": true, "min_amount": 10000},
+      "set": {"notes": "Check the receipt"},
+      "stop": true
+    },
+    {
+      "match": {"direction": "debit"},
+      "set": {"metadata": {"reviewed": "no"}}
+    }
+  ]
+}`
+
+var transactions = []mondo.Transaction{
+	{ID: "tx_1", Description: "PRET A MANGER", Amount: -350, Merchant: mondo.Merchant{Name: "Pret A Manger"}},
+	{ID: "tx_2", Description: "COSTA", Amount: -900, Merchant: mondo.Merchant{Name: "Costa"}},
+	{ID: "tx_3", Description: "AMAZON", Amount: -15000, Merchant: mondo.Merchant{Name: "Amazon", Online: true}},
+	{ID: "tx_4", Description: "Top up", Amount: 10000, IsLoad: true},
+	{ID: "tx_5", Description: "TESCO", Amount: -1200, Metadata: map[string]interface{}{"reviewed": "no"}},
+}
+
+func TestParseRules(t *testing.T) {
+	rs, err := ParseRules(strings.NewReader(ruleset))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(rs.Rules))
+	assert.Equal(t, "rule 3", rs.Rules[2].Name)
+
+	_, err = ParseRules(strings.NewReader(`{"rules": [{"match": {"merchant": "("}, "set": {"notes": "x"}}]}`))
+	assert.Error(t, err)
+
+	_, err = ParseRules(strings.NewReader(`{"rules": [{"match": {"direction": "sideways"}, "set": {"notes": "x"}}]}`))
+	assert.Error(t, err)
+
+	_, err = ParseRules(strings.NewReader(`{"rules": [{"match": {}}]}`))
+	assert.Error(t, err)
+}
+
+func TestPlan(t *testing.T) {
+	rs, err := ParseRules(strings.NewReader(ruleset))
+	assert.NoError(t, err)
+
+	changes := rs.Plan(transactions)
+	assert.Equal(t, 3, len(changes))
+
+	assert.Equal(t, "tx_1", changes[0].TransactionID)
+	assert.Equal(t, []string{"coffee", "rule 3"}, changes[0].Rules)
+	assert.Equal(t, map[string]string{"category": "coffee", "habit": "caffeine", "reviewed": "no"}, changes[0].Metadata)
+	assert.Equal(t, []string{"category", "habit", "reviewed"}, changes[0].Keys())
+
+	assert.Equal(t, "tx_2", changes[1].TransactionID)
+	assert.Equal(t, []string{"rule 3"}, changes[1].Rules)
+
+	assert.Equal(t, "tx_3", changes[2].TransactionID)
+	assert.Equal(t, []string{"big online purchases"}, changes[2].Rules)
+	assert.Equal(t, map[string]string{"notes": "Check the receipt"}, changes[2].Metadata)
+
+	// Transactions that already have the values rules would set are left alone.
+	tx := transactions[2]
+	tx.Notes = "Check the receipt"
+	assert.Nil(t, rs.Evaluate(tx))
+}
+
+func TestApply(t *testing.T) {
+	changes := []Change{
+		{TransactionID: "tx_1", Metadata: map[string]string{"category": "coffee"}},
+		{TransactionID: "tx_2", Metadata: map[string]string{"notes": "hi"}},
+	}
+
+	fake := &mondotest.FakeClient{}
+	n, err := Apply(fake, changes)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 2, fake.AnnotateTransactionCallCount())
+	id, metadata := fake.AnnotateTransactionArgsForCall(1)
+	assert.Equal(t, "tx_2", id)
+	assert.Equal(t, map[string]string{"notes": "hi"}, metadata)
+
+	fake.AnnotateTransactionReturnsOnCall(3, nil, fmt.Errorf("boom"))
+	n, err = Apply(fake, changes)
+	assert.Error(t, err)
+	assert.Equal(t, 1, n)
+}
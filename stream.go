@@ -0,0 +1,69 @@
+package mondo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// decodeTransactions reads a response of the form {"transactions": [...]}, calling fn with each transaction as it is decoded. Only one transaction is held in memory at a time. Other fields of the response are skipped.
+func decodeTransactions(r io.Reader, fn func(Transaction) error) error {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		if key != "transactions" {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return err
+			}
+			continue
+		}
+
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if tok == nil {
+			continue
+		}
+		if tok != json.Delim('[') {
+			return fmt.Errorf("expected an array of transactions, got %v", tok)
+		}
+
+		for dec.More() {
+			var tx Transaction
+			if err := dec.Decode(&tx); err != nil {
+				return err
+			}
+			if err := fn(tx); err != nil {
+				return err
+			}
+		}
+
+		if err := expectDelim(dec, ']'); err != nil {
+			return err
+		}
+	}
+
+	return expectDelim(dec, '}')
+}
+
+// expectDelim reads the next token, which must be delim.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("expected %v in response, got %v", delim, tok)
+	}
+	return nil
+}
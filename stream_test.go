@@ -0,0 +1,178 @@
+package mondo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// transactionsFixture returns a transactions response of n transactions with expanded merchants, like a large page from the API.
+func transactionsFixture(n int) []byte {
+	var b bytes.Buffer
+	b.WriteString(`{"transactions": [`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `{
+			"account_balance": %v,
+			"amount": -%v,
+			"created": "2015-08-22T12:20:%02dZ",
+			"currency": "GBP",
+			"description": "THE DE BEAUVOIR DELI C LONDON        GBR",
+			"id": "tx_%024d",
+			"merchant": {
+				"address": {
+					"address": "98 Southgate Road",
+					"city": "London",
+					"country": "GB",
+					"latitude": 51.54151,
+					"longitude": -0.08482400000002599,
+					"postcode": "N1 3JD",
+					"region": "Greater London"
+				},
+				"created": "2015-08-22T12:20:18Z",
+				"group_id": "grp_00008zIcpbBOaAr7TTP3sv",
+				"id": "merch_00008zIcpbAKe8shBxXUtl",
+				"logo": "https://pbs.twimg.com/profile_images/527043602623389696/68_SgUWJ.jpeg",
+				"emoji": "🍞",
+				"name": "The De Beauvoir Deli Co.",
+				"category": "eating_out"
+			},
+			"metadata": {"receipt": "yes"},
+			"notes": "Salmon sandwich 🍞",
+			"is_load": false,
+			"settled": "2015-08-23T12:20:18Z",
+			"category": "eating_out"
+		}`, 100000-i, i, i%60, i)
+	}
+	b.WriteString(`]}`)
+	return b.Bytes()
+}
+
+func TestDecodeTransactions(t *testing.T) {
+	var ids []string
+	collect := func(tx Transaction) error {
+		ids = append(ids, tx.ID)
+		return nil
+	}
+
+	err := decodeTransactions(strings.NewReader(`{"count": {"n": [1, 2]}, "transactions": [{"id": "tx_1"}, {"id": "tx_2"}], "more": true}`), collect)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tx_1", "tx_2"}, ids)
+
+	ids = nil
+	assert.NoError(t, decodeTransactions(strings.NewReader(`{"transactions": null}`), collect))
+	assert.NoError(t, decodeTransactions(strings.NewReader(`{"code": "bad_request"}`), collect))
+	assert.Empty(t, ids)
+
+	assert.Error(t, decodeTransactions(strings.NewReader(`{"transactions": {}}`), collect))
+	assert.Error(t, decodeTransactions(strings.NewReader(`[]`), collect))
+	assert.Error(t, decodeTransactions(strings.NewReader(`{"transactions": [{"id": "tx_1"}`), collect))
+	assert.Error(t, decodeTransactions(strings.NewReader(`<html>Bad Gateway</html>`), collect))
+
+	// Decoding stops as soon as fn fails.
+	ids = nil
+	stop := fmt.Errorf("stop")
+	err = decodeTransactions(bytes.NewReader(transactionsFixture(10)), func(tx Transaction) error {
+		ids = append(ids, tx.ID)
+		if len(ids) == 3 {
+			return stop
+		}
+		return nil
+	})
+	assert.Equal(t, stop, err)
+	assert.Len(t, ids, 3)
+}
+
+func TestEachTransaction(t *testing.T) {
+	setup()
+	defer teardown()
+
+	fixture := transactionsFixture(150)
+	mux.HandleFunc("/transactions",
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "merchant", r.FormValue("expand[]"))
+			assert.Equal(t, "150", r.FormValue("limit"))
+			w.Write(fixture)
+		},
+	)
+
+	client, err := Authenticate("some", "valid", "credentials", "here")
+	assert.NoError(t, err)
+
+	var streamed []Transaction
+	err = client.EachTransaction("account1", "", "", 150, func(tx Transaction) error {
+		streamed = append(streamed, tx)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	transactions, err := client.Transactions("account1", "", "", 150)
+	assert.NoError(t, err)
+	assert.Len(t, transactions, 150)
+	assert.Equal(t, transactions, streamed)
+	assert.Equal(t, "The De Beauvoir Deli Co.", streamed[149].Merchant.Name)
+	assert.Equal(t, -149, streamed[149].Amount)
+}
+
+// The benchmarks compare decoding a page of 1000 transactions by reading the whole body then unmarshalling it, as the client used to, against decoding it from a stream.
+
+func BenchmarkDecodeReadAll(b *testing.B) {
+	fixture := transactionsFixture(1000)
+	b.SetBytes(int64(len(fixture)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		var tresp struct {
+			Transactions []Transaction `json:"transactions"`
+		}
+		body, err := ioutil.ReadAll(bytes.NewReader(fixture))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := json.Unmarshal(body, &tresp); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeStream(b *testing.B) {
+	fixture := transactionsFixture(1000)
+	b.SetBytes(int64(len(fixture)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		var transactions []Transaction
+		err := decodeTransactions(bytes.NewReader(fixture), func(tx Transaction) error {
+			transactions = append(transactions, tx)
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeEachTransaction(b *testing.B) {
+	fixture := transactionsFixture(1000)
+	b.SetBytes(int64(len(fixture)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		total := 0
+		err := decodeTransactions(bytes.NewReader(fixture), func(tx Transaction) error {
+			total += tx.Amount
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
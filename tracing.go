@@ -0,0 +1,100 @@
+package mondo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// Tracer starts a span around each call a MondoClient makes to the API, such as Accounts or Transactions. Tracing is off unless MondoClient.Tracer is set, and the interface is small so that OpenTelemetry, or any other tracing library, can be adapted to it without go-mondo depending on one.
+type Tracer interface {
+	// Start starts a span named name, as a child of any span in ctx, and returns a context holding the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+
+	// Inject writes the trace context held in ctx to the headers of a request to the API, such as a W3C traceparent header.
+	Inject(ctx context.Context, header http.Header)
+}
+
+// Span is a call to the API being traced.
+type Span interface {
+	SetAttribute(key string, value interface{})
+
+	// End finishes the span, recording err if the call failed.
+	End(err error)
+}
+
+// The attributes set on spans. Account IDs are hashed, so that traces do not identify accounts.
+var (
+	AttributeStatusCode = "http.status_code"
+	AttributeAccountID  = "mondo.account_id_hash"
+	AttributePageSize   = "mondo.page_size"
+	AttributeRetryCount = "mondo.retry_count"
+)
+
+// WithContext returns a copy of the client whose calls are made with ctx. The calls are cancelled if ctx is, and their spans are children of any span ctx holds.
+func (m *MondoClient) WithContext(ctx context.Context) *MondoClient {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := *m
+	c.ctx = ctx
+	return &c
+}
+
+// call is a call to an endpoint, with the span tracing it, if any.
+type call struct {
+	ctx    context.Context
+	tracer Tracer
+	span   Span
+
+	// retries is the number of times the request has been retried.
+	retries int
+}
+
+// startCall starts a call to an endpoint, named after the client method making it.
+func (m *MondoClient) startCall(name string) *call {
+	c := &call{ctx: m.ctx, tracer: m.Tracer}
+	if c.ctx == nil {
+		c.ctx = context.Background()
+	}
+	if c.tracer != nil {
+		c.ctx, c.span = c.tracer.Start(c.ctx, "mondo."+name)
+	}
+	return c
+}
+
+func (c *call) setAttribute(key string, value interface{}) {
+	if c.span != nil {
+		c.span.SetAttribute(key, value)
+	}
+}
+
+// setAccount records the account a call is for, hashed.
+func (c *call) setAccount(accountId string) {
+	if c.span != nil && accountId != "" {
+		c.span.SetAttribute(AttributeAccountID, hashID(accountId))
+	}
+}
+
+// prepare makes a request part of the call, carrying its context and trace headers.
+func (c *call) prepare(req *http.Request) *http.Request {
+	req = req.WithContext(c.ctx)
+	if c.tracer != nil {
+		c.tracer.Inject(c.ctx, req.Header)
+	}
+	return req
+}
+
+func (c *call) end(err error) {
+	if c.span != nil {
+		c.span.SetAttribute(AttributeRetryCount, c.retries)
+		c.span.End(err)
+	}
+}
+
+// hashID returns a short, stable hash of an ID, which identifies it across traces without revealing it.
+func hashID(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:8])
+}
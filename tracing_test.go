@@ -0,0 +1,122 @@
+package mondo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type traceKey struct{}
+
+// recordingTracer records spans, and propagates the name of the current span as a header.
+type recordingTracer struct {
+	spans []*recordedSpan
+}
+
+type recordedSpan struct {
+	name       string
+	parent     string
+	attributes map[string]interface{}
+	err        error
+	ended      bool
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent, _ := ctx.Value(traceKey{}).(string)
+	s := &recordedSpan{name: name, parent: parent, attributes: map[string]interface{}{}}
+	t.spans = append(t.spans, s)
+	return context.WithValue(ctx, traceKey{}, name), s
+}
+
+func (t *recordingTracer) Inject(ctx context.Context, header http.Header) {
+	if name, ok := ctx.Value(traceKey{}).(string); ok {
+		header.Set("X-Trace", name)
+	}
+}
+
+func (s *recordedSpan) SetAttribute(key string, value interface{}) {
+	s.attributes[key] = value
+}
+
+func (s *recordedSpan) End(err error) {
+	s.err = err
+	s.ended = true
+}
+
+func TestTracing(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var header string
+	mux.HandleFunc("/transactions",
+		func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header.Get("X-Trace")
+			fmt.Fprint(w, `{"transactions": []}`)
+		},
+	)
+	mux.HandleFunc("/balance",
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(401)
+		},
+	)
+
+	client, err := Authenticate("some", "valid", "credentials", "here")
+	assert.NoError(t, err)
+
+	// Without a tracer, calls are not traced.
+	_, err = client.Transactions("account1", "", "", 50)
+	assert.NoError(t, err)
+	assert.Equal(t, "", header)
+
+	tracer := &recordingTracer{}
+	client.Tracer = tracer
+	traced := client.WithContext(context.WithValue(context.Background(), traceKey{}, "request"))
+
+	_, err = traced.Transactions("account1", "", "", 50)
+	assert.NoError(t, err)
+	assert.Equal(t, "mondo.Transactions", header)
+
+	_, err = traced.Balance("account1")
+	assert.Equal(t, ErrUnauthenticatedRequest, err)
+
+	// Arguments that are rejected before calling the API are not traced.
+	_, err = traced.Balance("")
+	assert.Error(t, err)
+
+	if assert.Len(t, tracer.spans, 2) {
+		s := tracer.spans[0]
+		assert.Equal(t, "mondo.Transactions", s.name)
+		assert.Equal(t, "request", s.parent)
+		assert.True(t, s.ended)
+		assert.NoError(t, s.err)
+		assert.Equal(t, map[string]interface{}{
+			AttributeStatusCode: 200,
+			AttributeAccountID:  hashID("account1"),
+			AttributePageSize:   50,
+			AttributeRetryCount: 0,
+		}, s.attributes)
+		assert.NotContains(t, s.attributes[AttributeAccountID], "account1")
+
+		s = tracer.spans[1]
+		assert.Equal(t, "mondo.Balance", s.name)
+		assert.Equal(t, ErrUnauthenticatedRequest, s.err)
+		assert.Equal(t, 401, s.attributes[AttributeStatusCode])
+	}
+}
+
+func TestWithContextCancels(t *testing.T) {
+	setup()
+	defer teardown()
+
+	client, err := Authenticate("some", "valid", "credentials", "here")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = client.WithContext(ctx).Accounts()
+	assert.Error(t, err)
+}
@@ -1,36 +1,185 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"log/slog"
 	"os"
 
-	log "github.com/cihub/seelog"
 	"github.com/sjwhitworth/gomondo"
 )
 
+const usage = `Usage: webhook <command> [flags]
+
+Commands:
+  serve     receive webhook events and fan them out to sinks
+  register  register a URL as a webhook against an account
+  list      list the webhooks registered against an account
+  delete    delete a webhook by ID
+
+Run "webhook <command> -h" for the flags each command accepts.
+`
+
 func main() {
-	defer log.Flush()
+	os.Exit(run(os.Args[1:]))
+}
 
+func run(args []string) int {
+	// Log to stderr, so that events written to stdout by the stdout sink stay machine readable. Requests to the API are logged at debug level.
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel()})))
+	mondo.DefaultLogger = slog.Default()
+
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	var err error
+	switch args[0] {
+	case "serve":
+		err = serve(args[1:])
+	case "register":
+		err = register(args[1:])
+	case "list":
+		err = list(args[1:])
+	case "delete":
+		err = remove(args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprint(os.Stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%v", args[0], usage)
+		return 2
+	}
+
+	if err != nil {
+		slog.Error(err.Error())
+		return 1
+	}
+	return 0
+}
+
+// authenticate returns an authenticated MondoClient using credentials from the environment.
+func authenticate() (*mondo.MondoClient, error) {
 	clientId := os.Getenv("MONDO_CLIENT_ID")
 	clientSecret := os.Getenv("MONDO_CLIENT_SECRET")
 	userName := os.Getenv("MONDO_USERNAME")
 	password := os.Getenv("MONDO_PASSWORD")
 
 	// Authenticate with Mondo, and return an authenticated MondoClient.
-	client, err := mondo.Authenticate(clientId, clientSecret, userName, password)
-	if err != nil {
-		panic(err)
+	return mondo.Authenticate(clientId, clientSecret, userName, password)
+}
+
+// resolveAccount returns accountId if set, otherwise the ID of the first account.
+func resolveAccount(client *mondo.MondoClient, accountId string) (string, error) {
+	if accountId != "" {
+		return accountId, nil
 	}
 
-	// Retrieve all of the accounts.
 	acs, err := client.Accounts()
 	if err != nil {
-		panic(err)
+		return "", err
+	}
+
+	if len(acs) == 0 {
+		return "", fmt.Errorf("no accounts with Mondo found")
+	}
+
+	return acs[0].ID, nil
+}
+
+func register(args []string) error {
+	fs := flag.NewFlagSet("register", flag.ExitOnError)
+	accountId := fs.String("account", "", "account ID to register against (defaults to the first account)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: webhook register [-account ID] URL")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("register takes exactly one URL")
+	}
+
+	client, err := authenticate()
+	if err != nil {
+		return err
 	}
 
-	// Grab our account ID.
-	accountId := acs[0].ID
+	id, err := resolveAccount(client, *accountId)
+	if err != nil {
+		return err
+	}
+
+	webhook, err := client.RegisterWebhook(id, fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("error registering webhook: %v", err)
+	}
+
+	fmt.Printf("%v\t%v\t%v\n", webhook.Id, webhook.AccountId, webhook.Url)
+	return nil
+}
+
+func list(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	accountId := fs.String("account", "", "account ID to list webhooks for (defaults to the first account)")
+	fs.Parse(args)
+
+	client, err := authenticate()
+	if err != nil {
+		return err
+	}
+
+	id, err := resolveAccount(client, *accountId)
+	if err != nil {
+		return err
+	}
+
+	webhooks, err := client.ListWebhooks(id)
+	if err != nil {
+		return fmt.Errorf("error listing webhooks: %v", err)
+	}
+
+	for _, w := range webhooks {
+		fmt.Printf("%v\t%v\t%v\n", w.Id, w.AccountId, w.Url)
+	}
+	return nil
+}
+
+func remove(args []string) error {
+	fs := flag.NewFlagSet("delete", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: webhook delete WEBHOOK_ID...")
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("delete takes at least one webhook ID")
+	}
+
+	client, err := authenticate()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range fs.Args() {
+		if err := client.DeleteWebhook(id); err != nil {
+			return fmt.Errorf("error deleting webhook: %v", err)
+		}
+		slog.Info("Deleted webhook", "id", id)
+	}
+	return nil
+}
 
-	if _, err := client.RegisterWebhook(accountId, "YOUR_URL_HERE"); err != nil {
-		log.Errorf("Error registering webhook: %v", err)
+// logLevel returns the level set by MONDO_LOG_LEVEL, such as debug or warn, defaulting to info.
+func logLevel() slog.Level {
+	var level slog.Level
+	if v := os.Getenv("MONDO_LOG_LEVEL"); v != "" {
+		if err := level.UnmarshalText([]byte(v)); err != nil {
+			fmt.Fprintf(os.Stderr, "Ignoring MONDO_LOG_LEVEL: %v\n", err)
+		}
 	}
+	return level
 }
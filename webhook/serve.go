@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sjwhitworth/gomondo"
+)
+
+// sinkFlags collects repeated -sink flags.
+type sinkFlags []string
+
+func (s *sinkFlags) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *sinkFlags) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+func serve(args []string) error {
+	var specs sinkFlags
+
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", ":8080", "address to listen on")
+	path := fs.String("path", "/", "path to receive webhook events on")
+	certFile := fs.String("tls-cert", "", "TLS certificate file; serves HTTPS when set with -tls-key")
+	keyFile := fs.String("tls-key", "", "TLS key file")
+	shutdownTimeout := fs.Duration("shutdown-timeout", 10*time.Second, "time to wait for in-flight events on shutdown")
+	token := fs.String("token", os.Getenv("MONDO_WEBHOOK_TOKEN"), "token events must carry in their token query parameter; defaults to MONDO_WEBHOOK_TOKEN, and is required")
+	fs.Var(&specs, "sink", "where to send events; repeatable. One of stdout, file:PATH, exec:COMMAND or forward:URL")
+	fs.Parse(args)
+
+	if (*certFile == "") != (*keyFile == "") {
+		return fmt.Errorf("-tls-cert and -tls-key must be set together")
+	}
+
+	// Sinks run commands and forward requests, so events are only accepted from whoever holds the token.
+	if *token == "" {
+		return fmt.Errorf("-token or MONDO_WEBHOOK_TOKEN must be set, and the webhook registered with ?token=... on its URL")
+	}
+
+	if len(specs) == 0 {
+		specs = sinkFlags{"stdout"}
+	}
+
+	var sinks []sink
+	for _, spec := range specs {
+		s, err := parseSink(spec)
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+		sinks = append(sinks, s)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(*path, &eventHandler{token: *token, sinks: sinks})
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      2 * time.Minute,
+		IdleTimeout:       2 * time.Minute,
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		slog.Info("Listening for webhook events", "addr", *addr, "path", *path)
+		if *certFile != "" {
+			errc <- srv.ListenAndServeTLS(*certFile, *keyFile)
+		} else {
+			errc <- srv.ListenAndServe()
+		}
+	}()
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigc)
+
+	select {
+	case err := <-errc:
+		return err
+	case sig := <-sigc:
+		slog.Info("Shutting down", "signal", sig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	return srv.Shutdown(ctx)
+}
+
+// The largest event accepted, which is far more than a transaction needs.
+const maxEventSize = 64 << 10
+
+// eventHandler receives webhook events and fans them out to every sink. Events must carry the token in their token query parameter.
+type eventHandler struct {
+	token string
+	sinks []sink
+}
+
+func (h *eventHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.token == "" || subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(h.token)) != 1 {
+		slog.Warn("Rejecting event without a valid token", "remote_addr", r.RemoteAddr)
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	b, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxEventSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "event too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var event mondo.WebhookRequest
+	if err := json.Unmarshal(b, &event); err != nil {
+		slog.Warn("Discarding malformed event", "error", err)
+		http.Error(w, "malformed event", http.StatusBadRequest)
+		return
+	}
+
+	if event.Data != nil {
+		slog.Info("Received event", "type", event.Type, "id", event.Data.ID)
+	} else {
+		slog.Info("Received event", "type", event.Type)
+	}
+
+	// Compact the original body so that every sink sees a single line of JSON, including any fields we do not know about.
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, b); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	line := buf.Bytes()
+
+	failed := false
+	for _, s := range h.sinks {
+		if err := s.Send(line); err != nil {
+			slog.Error("Error sending event", "sink", s, "error", err)
+			failed = true
+		}
+	}
+
+	// Mondo retries failed deliveries, so let it know if any sink missed the event.
+	if failed {
+		http.Error(w, "failed to deliver event", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
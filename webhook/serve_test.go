@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingSink records the events it is sent, and fails if err is set.
+type recordingSink struct {
+	events []string
+	err    error
+}
+
+func (s *recordingSink) Send(event []byte) error {
+	s.events = append(s.events, string(event))
+	return s.err
+}
+
+func (s *recordingSink) Close() error {
+	return nil
+}
+
+const event = `{
+	"type": "transaction.created",
+	"data": {"id": "tx_1", "account_id": "acc_1", "amount": -350, "decline_reason": "INSUFFICIENT_FUNDS"}
+}`
+
+func post(h http.Handler, target, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", target, strings.NewReader(body)))
+	return w
+}
+
+func TestEventHandlerAuth(t *testing.T) {
+	recorded := &recordingSink{}
+	h := &eventHandler{token: "s3cret", sinks: []sink{recorded}}
+
+	for _, target := range []string{"/", "/?token=", "/?token=wrong", "/?token=s3cre", "/?token=s3cret2"} {
+		assert.Equal(t, http.StatusForbidden, post(h, target, event).Code, target)
+	}
+	assert.Empty(t, recorded.events)
+
+	// A handler without a token accepts nothing.
+	assert.Equal(t, http.StatusForbidden, post(&eventHandler{sinks: []sink{recorded}}, "/?token=", event).Code)
+	assert.Empty(t, recorded.events)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/?token=s3cret", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestEventHandlerLimits(t *testing.T) {
+	recorded := &recordingSink{}
+	h := &eventHandler{token: "s3cret", sinks: []sink{recorded}}
+
+	large := `{"type": "transaction.created", "padding": "` + strings.Repeat("x", maxEventSize) + `"}`
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post(h, "/?token=s3cret", large).Code)
+	assert.Equal(t, http.StatusBadRequest, post(h, "/?token=s3cret", `{"type": `).Code)
+	assert.Empty(t, recorded.events)
+}
+
+func TestEventHandlerSinks(t *testing.T) {
+	first, second := &recordingSink{}, &recordingSink{}
+	h := &eventHandler{token: "s3cret", sinks: []sink{first, second}}
+
+	assert.Equal(t, http.StatusOK, post(h, "/?token=s3cret", event).Code)
+
+	// Every sink gets the event as one line, with fields this package does not know about.
+	want := `{"type":"transaction.created","data":{"id":"tx_1","account_id":"acc_1","amount":-350,"decline_reason":"INSUFFICIENT_FUNDS"}}`
+	assert.Equal(t, []string{want}, first.events)
+	assert.Equal(t, []string{want}, second.events)
+
+	// If any sink fails, the event is still sent to the others, and Mondo is told so that it retries.
+	first.err = fmt.Errorf("disk full")
+	assert.Equal(t, http.StatusInternalServerError, post(h, "/?token=s3cret", event).Code)
+	assert.Len(t, second.events, 2)
+}
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	s := &writerSink{name: "buffer", w: &buf}
+	assert.NoError(t, s.Send([]byte(`{"a":1}`)))
+	assert.NoError(t, s.Send([]byte(`{"b":2}`)))
+	assert.Equal(t, "{\"a\":1}\n{\"b\":2}\n", buf.String())
+
+	for _, spec := range []string{"file:", "exec:", "forward:", "carrier-pigeon"} {
+		_, err := parseSink(spec)
+		assert.Error(t, err, spec)
+	}
+}
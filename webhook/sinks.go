@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sink is a destination for webhook events. Send is passed a single JSON encoded event, without a trailing newline.
+type sink interface {
+	Send(event []byte) error
+	Close() error
+}
+
+// parseSink builds a sink from a flag value such as "stdout", "file:/var/log/mondo.jsonl", "exec:notify-send" or "forward:https://example.com/hook".
+func parseSink(spec string) (sink, error) {
+	kind, arg := spec, ""
+	if i := strings.Index(spec, ":"); i >= 0 {
+		kind, arg = spec[:i], spec[i+1:]
+	}
+
+	switch kind {
+	case "stdout":
+		return &writerSink{name: "stdout", w: os.Stdout}, nil
+	case "file":
+		if arg == "" {
+			return nil, fmt.Errorf("file sink requires a path")
+		}
+		f, err := os.OpenFile(arg, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, err
+		}
+		return &writerSink{name: spec, w: f, closer: f}, nil
+	case "exec":
+		if arg == "" {
+			return nil, fmt.Errorf("exec sink requires a command")
+		}
+		return &execSink{command: arg}, nil
+	case "forward":
+		if arg == "" {
+			return nil, fmt.Errorf("forward sink requires a URL")
+		}
+		return &forwardSink{url: arg, client: &http.Client{Timeout: 10 * time.Second}}, nil
+	}
+
+	return nil, fmt.Errorf("unknown sink %q", spec)
+}
+
+// writerSink writes events as JSON lines.
+type writerSink struct {
+	name   string
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+func (s *writerSink) Send(event []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.w.Write(event); err != nil {
+		return err
+	}
+	_, err := s.w.Write([]byte{'\n'})
+	return err
+}
+
+func (s *writerSink) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+func (s *writerSink) String() string {
+	return s.name
+}
+
+// execSink runs a shell command for each event, passing the event on stdin. The command's output goes to stderr, so that it is not mixed into events written by a stdout sink.
+type execSink struct {
+	command string
+}
+
+func (s *execSink) Send(event []byte) error {
+	cmd := exec.Command("sh", "-c", s.command)
+	cmd.Stdin = bytes.NewReader(event)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func (s *execSink) Close() error {
+	return nil
+}
+
+func (s *execSink) String() string {
+	return "exec:" + s.command
+}
+
+// forwardSink POSTs each event to another URL.
+type forwardSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *forwardSink) Send(event []byte) error {
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(event))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response: %v", resp.Status)
+	}
+	return nil
+}
+
+func (s *forwardSink) Close() error {
+	return nil
+}
+
+func (s *forwardSink) String() string {
+	return "forward:" + s.url
+}